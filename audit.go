@@ -0,0 +1,155 @@
+package gotestdox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Unmentioned returns those of funcs, the names of some functions, that
+// aren't mentioned in any of sentences, such as those that [Prettify] makes
+// from the names of a package's tests. A sentence mentions a function if,
+// ignoring case, it contains the function's name as a word, as in "ParseJSON
+// returns error", or the words that Prettify splits the name into, as in
+// "Parse JSON returns error". The result is in the same order as funcs.
+//
+// This is only a heuristic: a test can exercise a function without naming it,
+// and a short name such as 'New' can be mentioned by coincidence. But a
+// function that no test mentions is probably not documented by the tests.
+func Unmentioned(funcs, sentences []string) []string {
+	var words [][]string
+	for _, s := range sentences {
+		words = append(words, strings.Fields(strings.ToLower(strings.TrimPrefix(s, "[fuzz] "))))
+	}
+	var missing []string
+	for _, fn := range funcs {
+		name := []string{strings.ToLower(fn)}
+		split := strings.Fields(strings.ToLower(Prettify("Test" + fn)))
+		if !slices.ContainsFunc(words, func(w []string) bool {
+			return containsRun(w, name) || containsRun(w, split)
+		}) {
+			missing = append(missing, fn)
+		}
+	}
+	return missing
+}
+
+// containsRun reports whether words contains all of run, consecutively and
+// in order.
+func containsRun(words, run []string) bool {
+	if len(run) == 0 {
+		return false
+	}
+	for i := 0; i+len(run) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(run)], run) {
+			return true
+		}
+	}
+	return false
+}
+
+// Audit runs 'go list' and 'go test -list' with any extra args supplied by the
+// user, to find the exported functions and the tests in each package, and
+// prints the name of each function that no test's sentence mentions (see
+// [Unmentioned]), under the name of its package. Methods are not included.
+// Since the args are given to both commands, they should be only package
+// patterns, such as './...', and build flags, such as '-tags'.
+//
+// If there were any such functions, or either command returned some error,
+// td.OK will be false. Errors are reported to td.Stderr.
+func (td *TestDoxer) Audit(userArgs []string) {
+	td.OK = true
+	pkgs, err := td.exportedFuncs(userArgs)
+	if err != nil {
+		td.OK = false
+		fmt.Fprintln(td.Stderr, err)
+		return
+	}
+	args := append([]string{"test", "-list", "."}, userArgs...)
+	cmd := exec.Command(td.goCommand(), args...)
+	cmd.Stderr = td.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// without the full list of tests, every function might look
+		// unmentioned
+		td.OK = false
+		fmt.Fprintln(td.Stderr, cmd.Args, err)
+		return
+	}
+	sentences := map[string][]string{}
+	listedTests(out, func(pkg string, names []string) {
+		for _, name := range names {
+			sentences[pkg] = append(sentences[pkg], td.prettify(name))
+		}
+	})
+	for _, pkg := range pkgs {
+		missing := Unmentioned(pkg.funcs, sentences[pkg.path])
+		if len(missing) == 0 {
+			continue
+		}
+		td.OK = false
+		fmt.Fprintln(td.Stdout, pkg.path+":")
+		for _, fn := range missing {
+			fmt.Fprintf(td.Stdout, " %s: no test mentions it\n", fn)
+		}
+		fmt.Fprintln(td.Stdout)
+	}
+}
+
+// packageFuncs lists the exported functions declared in a package.
+type packageFuncs struct {
+	path  string
+	funcs []string
+}
+
+// exportedFuncs runs 'go list' with args, and parses the (non-test) Go files
+// of each package it lists, to find their exported functions.
+func (td *TestDoxer) exportedFuncs(args []string) ([]packageFuncs, error) {
+	cmd := exec.Command(td.goCommand(), append([]string{"list", "-json"}, args...)...)
+	cmd.Stderr = td.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v %w", cmd.Args, err)
+	}
+	var pkgs []packageFuncs
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p struct {
+			ImportPath string
+			Dir        string
+			GoFiles    []string
+		}
+		err := dec.Decode(&p)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		pkg := packageFuncs{path: p.ImportPath}
+		fset := token.NewFileSet()
+		for _, name := range p.GoFiles {
+			f, err := parser.ParseFile(fset, filepath.Join(p.Dir, name), nil, parser.SkipObjectResolution)
+			if err != nil {
+				return nil, err
+			}
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if ok && fn.Recv == nil && fn.Name.IsExported() {
+					pkg.funcs = append(pkg.funcs, fn.Name.Name)
+				}
+			}
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,42 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmentioned_ReturnsFunctionsNoSentenceMentions(t *testing.T) {
+	t.Parallel()
+	funcs := []string{"ParseJSON", "Prettify", "Check", "Diff", "NewEvent"}
+	sentences := []string{
+		"ParseJSON returns error for invalid input",
+		"[fuzz] Prettify",
+		"Filter calls check on each name",
+		"New event sets sentence",
+	}
+	want := []string{"Diff"}
+	got := gotestdox.Unmentioned(funcs, sentences)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestUnmentioned_DoesNotMatchPartOfAWord(t *testing.T) {
+	t.Parallel()
+	want := []string{"Parse"}
+	got := gotestdox.Unmentioned([]string{"Parse"}, []string{"Parser handles empty input"})
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestUnmentioned_ReturnsAllFunctionsWhenThereAreNoSentences(t *testing.T) {
+	t.Parallel()
+	want := []string{"A", "B"}
+	got := gotestdox.Unmentioned([]string{"A", "B"}, nil)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
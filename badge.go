@@ -0,0 +1,36 @@
+package gotestdox
+
+import "fmt"
+
+// A Badge describes a test run in the JSON format understood by the
+// shields.io endpoint badge, so that a README can show the current state of
+// the tests. See https://shields.io/badges/endpoint-badge.
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// NewBadge returns a [Badge] summarising result, such as "120 passing". The
+// badge is green if every test passed, yellow if they passed but some were
+// skipped, or red if any failed, or the run was otherwise not OK.
+func NewBadge(result RunResult) Badge {
+	b := Badge{
+		SchemaVersion: 1,
+		Label:         "tests",
+		Message:       fmt.Sprintf("%d passing", result.Passed),
+		Color:         "green",
+	}
+	if result.Skipped > 0 {
+		b.Message += fmt.Sprintf(", %d skipped", result.Skipped)
+		b.Color = "yellow"
+	}
+	if result.Failed > 0 {
+		b.Message = fmt.Sprintf("%d failing, ", result.Failed) + b.Message
+	}
+	if result.Failed > 0 || !result.OK {
+		b.Color = "red"
+	}
+	return b
+}
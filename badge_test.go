@@ -0,0 +1,67 @@
+package gotestdox_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewBadge_SummarisesResultWithColourForItsState(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name   string
+		result gotestdox.RunResult
+		want   gotestdox.Badge
+	}{
+		{
+			name:   "all passing",
+			result: gotestdox.RunResult{OK: true, Passed: 120},
+			want:   gotestdox.Badge{SchemaVersion: 1, Label: "tests", Message: "120 passing", Color: "green"},
+		},
+		{
+			name:   "some skipped",
+			result: gotestdox.RunResult{OK: true, Passed: 118, Skipped: 2},
+			want:   gotestdox.Badge{SchemaVersion: 1, Label: "tests", Message: "118 passing, 2 skipped", Color: "yellow"},
+		},
+		{
+			name:   "some failing",
+			result: gotestdox.RunResult{Passed: 117, Failed: 3, Skipped: 2},
+			want:   gotestdox.Badge{SchemaVersion: 1, Label: "tests", Message: "3 failing, 117 passing, 2 skipped", Color: "red"},
+		},
+		{
+			name:   "not OK with no failing tests",
+			result: gotestdox.RunResult{Passed: 5},
+			want:   gotestdox.Badge{SchemaVersion: 1, Label: "tests", Message: "5 passing", Color: "red"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := gotestdox.NewBadge(tc.result)
+			if !cmp.Equal(tc.want, got) {
+				t.Error(cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestFilter_CountsSkippedTests(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"skip","Package":"p","Test":"TestB"}
+{"Action":"skip","Package":"p","Test":"TestC"}
+{"Action":"pass","Package":"p"}`),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Skipped != 2 {
+		t.Errorf("want 2 skipped, got %d", result.Skipped)
+	}
+}
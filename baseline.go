@@ -0,0 +1,37 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readBaseline reads the events from the 'go test -json' output saved in the
+// file at path, for use as [TestDoxer.Baseline]. As with [TestDoxer.Filter],
+// lines that aren't meant to be JSON are skipped, but any other line that
+// can't be parsed is an error, since a damaged baseline would make every test
+// look new. The result is never nil, even if the file holds no events.
+func readBaseline(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	events := []Event{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if !strings.HasPrefix(strings.TrimSpace(scanner.Text()), "{") {
+			continue
+		}
+		event, err := ParseJSON(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,99 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilter_MarksTestsMissingFromBaselineAsNew(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestOld"}
+{"Action":"pass","Package":"p","Test":"TestNew"}
+{"Action":"pass","Package":"p","Test":"TestMoved"}
+{"Action":"pass","Package":"p"}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+		Baseline: []gotestdox.Event{
+			{Action: "fail", Package: "p", Test: "TestOld"},
+			{Action: "pass", Package: "q", Test: "TestMoved"},
+		},
+	}
+	td.Filter()
+	want := "p:\n ✔ Moved (0.00s) (new)\n ✔ New (0.00s) (new)\n ✔ Old (0.00s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_MarksEveryTestAsNewWithEmptyBaseline(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}`),
+		Stdout:   buf,
+		Stderr:   io.Discard,
+		Baseline: []gotestdox.Event{},
+	}
+	td.Filter()
+	want := "p:\n ✔ A (0.00s) (new)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestBaselineFlag_ReadsEventsFromSavedRun(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "base.json")
+	data := "=== RUN   TestA\n" + `{"Action":"pass","Package":"p","Test":"TestA"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	td := gotestdox.NewTestDoxer()
+	_, err := gotestdox.ParseArgs(td.FlagSet(), []string{"-baseline", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.Event{{Action: "pass", Package: "p", Test: "TestA"}}
+	if !cmp.Equal(want, td.Baseline) {
+		t.Error(cmp.Diff(want, td.Baseline))
+	}
+}
+
+func TestBaselineFlag_ErrorsOnInvalidJSON(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "base.json")
+	if err := os.WriteFile(path, []byte("{bogus}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	td := gotestdox.NewTestDoxer()
+	_, err := gotestdox.ParseArgs(td.FlagSet(), []string{"-baseline", path})
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestEventString_MarksNewTest(t *testing.T) {
+	color.NoColor = true
+	event := gotestdox.Event{
+		Action:   gotestdox.ActionPass,
+		Sentence: "Brand new thing works",
+		Elapsed:  0.5,
+		New:      true,
+	}
+	want := " ✔ Brand new thing works (0.50s) (new)"
+	got := event.String()
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
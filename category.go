@@ -0,0 +1,58 @@
+package gotestdox
+
+import (
+	"regexp"
+	"sync"
+)
+
+// failureCategory associates a pattern in the output of a failing test with a
+// short label describing the kind of failure.
+type failureCategory struct {
+	pattern *regexp.Regexp
+	label   string
+}
+
+// CategoryFailPattern is the category of a test that passed, but was
+// marked failed because its output matched [TestDoxer.FailPattern].
+const CategoryFailPattern = "matched fail pattern"
+
+var (
+	categoriesMu sync.Mutex
+
+	// categories are checked in order, so the timeout pattern must come
+	// before the more general panic pattern.
+	categories = []failureCategory{
+		{regexp.MustCompile(`(?m)^panic: test timed out after`), "timeout"},
+		{regexp.MustCompile(`(?m)^WARNING: DATA RACE$`), "race"},
+		{regexp.MustCompile(`(?m)^panic: `), "panic"},
+	}
+)
+
+// RegisterFailureCategory adds a new category of test failure, identified by
+// label, to those recognised by [FailureCategory]. A failing test belongs to
+// this category if its output matches pattern, and it doesn't belong to any of
+// the categories registered before it. The built-in categories are "timeout",
+// "race", and "panic".
+//
+// RegisterFailureCategory is safe to call concurrently, but it's usually best
+// to call it at the start of the program (or in an init function), before any
+// test results are processed.
+func RegisterFailureCategory(pattern *regexp.Regexp, label string) {
+	categoriesMu.Lock()
+	defer categoriesMu.Unlock()
+	categories = append(categories, failureCategory{pattern, label})
+}
+
+// FailureCategory returns the label of the first registered category (see
+// [RegisterFailureCategory]) whose pattern matches the given test output, or
+// the empty string if there is none.
+func FailureCategory(output string) string {
+	categoriesMu.Lock()
+	defer categoriesMu.Unlock()
+	for _, c := range categories {
+		if c.pattern.MatchString(output) {
+			return c.label
+		}
+	}
+	return ""
+}
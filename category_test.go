@@ -0,0 +1,39 @@
+package gotestdox_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+)
+
+func TestFailureCategory_RecognisesBuiltInCategories(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		output, want string
+	}{
+		{"    foo_test.go:9: oh no\n", ""},
+		{"panic: runtime error: index out of range [1] with length 1 [recovered]\n", "panic"},
+		{"panic: test timed out after 10m0s\nrunning tests:\n\tTestSlow (10m0s)\n", "timeout"},
+		{"==================\nWARNING: DATA RACE\nWrite at 0x00c000012345 by goroutine 7:\n", "race"},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.FailureCategory(tc.output)
+		if tc.want != got {
+			t.Errorf("%q: want %q, got %q", tc.output, tc.want, got)
+		}
+	}
+}
+
+func TestRegisterFailureCategory_AddsCategoryCheckedAfterBuiltIns(t *testing.T) {
+	t.Parallel()
+	gotestdox.RegisterFailureCategory(regexp.MustCompile(`called from a goroutine|panic: `), "goroutine")
+	got := gotestdox.FailureCategory("    testing.go:1: test executed FailNow called from a goroutine\n")
+	if got != "goroutine" {
+		t.Errorf("want %q, got %q", "goroutine", got)
+	}
+	got = gotestdox.FailureCategory("panic: oh no\n")
+	if got != "panic" {
+		t.Errorf("want built-in category %q to take precedence, got %q", "panic", got)
+	}
+}
@@ -0,0 +1,129 @@
+package gotestdox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangedPackages returns patterns, such as "./parser", naming the packages
+// in the directory dir (or below it) that contain files changed since the
+// Git commit base, according to 'git diff'. Files not yet known to Git count
+// as changed too, unless Git is set to ignore them.
+//
+// Only Go source files, and files under a 'testdata' directory, are taken
+// into account. A change to a file under testdata counts as a change to the
+// package containing that directory. The patterns are relative to dir, and
+// sorted.
+//
+// If dir isn't in a Git repository, or base isn't a valid commit, the error
+// says so.
+func ChangedPackages(dir, base string) ([]string, error) {
+	// outside a repository, 'git diff' would compare files instead
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+		if _, err := git(dir, "rev-parse", "--git-dir"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%q is not a valid commit", base)
+	}
+	changed, err := git(dir, "diff", "--name-only", "--relative", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	patterns := []string{}
+	for _, name := range append(changed, untracked...) {
+		pkg, ok := packageOf(name)
+		if !ok {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, pkg)); err != nil || !info.IsDir() {
+			// the whole package was deleted
+			continue
+		}
+		pattern := "./" + pkg
+		if pkg == "." {
+			pattern = "."
+		}
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	slices.Sort(patterns)
+	return patterns, nil
+}
+
+// packageOf returns the directory of the package that the file name (a
+// slash-separated path) belongs to, if it is a Go source file, or is under a
+// testdata directory.
+func packageOf(name string) (string, bool) {
+	elems := strings.Split(name, "/")
+	if i := slices.Index(elems, "testdata"); i >= 0 {
+		return path.Join(append([]string{"."}, elems[:i]...)...), true
+	}
+	if !strings.HasSuffix(name, ".go") {
+		return "", false
+	}
+	return path.Dir(name), true
+}
+
+// git runs the git command with args in dir, and returns the lines of its
+// output. Any error includes what git printed to its standard error.
+func git(dir string, args ...string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// onlyChanged is the [flag.Value] for the -only-changed flag, which may be
+// given on its own, to compare with HEAD, or with the name of a commit to
+// compare with.
+type onlyChanged struct {
+	td *TestDoxer
+}
+
+func (o onlyChanged) String() string {
+	if o.td == nil {
+		return ""
+	}
+	return o.td.OnlyChanged
+}
+
+func (o onlyChanged) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	switch {
+	case err != nil:
+		o.td.OnlyChanged = s
+	case on:
+		o.td.OnlyChanged = "HEAD"
+	default:
+		o.td.OnlyChanged = ""
+	}
+	return nil
+}
+
+func (o onlyChanged) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,65 @@
+package gotestdox_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangedPackages_ListsPackagesWithChangedGoOrTestdataFiles(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	for _, name := range []string{"root.go", "a/a.go", "b/b.go", "c/c.go", "c/testdata/golden.txt", "d/README.md"} {
+		write(name, "original\n")
+	}
+	run("init", "-q")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	write("a/a.go", "changed\n")
+	write("c/testdata/golden.txt", "changed\n")
+	write("d/README.md", "changed\n")
+	write("e/new_test.go", "untracked\n")
+	got, err := gotestdox.ChangedPackages(dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"./a", "./c", "./e"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestChangedPackages_ErrorsOutsideGitRepository(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	_, err := gotestdox.ChangedPackages(t.TempDir(), "HEAD")
+	if err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,208 @@
+package gotestdox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A Result records the sentence that [Prettify] made from a test name, and
+// any problems [Check] found with it.
+type Result struct {
+	Name     string
+	Sentence string
+	Warnings []string
+}
+
+// Check prettifies each of the given test names, and reports any that don't
+// make good sentences: for example, because the sentence still contains an
+// underscore, or consists of a single word, or has no verb saying what its
+// subject does, or the name starts with a lower-case letter after 'Test'. Each name gets a [Result], in the same
+// order as names, whose Warnings are empty if the name is fine.
+//
+// This lets a package check, in its own tests, that all its test names read
+// well:
+//
+//	for _, r := range gotestdox.Check(names) {
+//		for _, w := range r.Warnings {
+//			t.Errorf("%s: %s", r.Name, w)
+//		}
+//	}
+func Check(names []string) []Result {
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		sentence := Prettify(name)
+		results = append(results, Result{
+			Name:     name,
+			Sentence: sentence,
+			Warnings: warnings(name, sentence),
+		})
+	}
+	return results
+}
+
+func warnings(name, sentence string) []string {
+	var ws []string
+	if !strings.HasPrefix(name, "Test") && !strings.HasPrefix(name, "Fuzz") {
+		ws = append(ws, "not a test name")
+	}
+	if strings.TrimPrefix(sentence, "[fuzz] ") == "" {
+		return append(ws, "empty sentence")
+	}
+	words := strings.Fields(strings.TrimPrefix(sentence, "[fuzz] "))
+	if len(words) == 1 && !strings.HasPrefix(name, "Fuzz") {
+		// names the thing under test, but says nothing about it
+		ws = append(ws, "single word")
+	}
+	if strings.HasPrefix(name, "Test") && !hasVerb(name) {
+		ws = append(ws, "no verb")
+	}
+	if slices.ContainsFunc(words, initialismSoup) {
+		ws = append(ws, "initialism soup")
+	}
+	if strings.ContainsRune(sentence, '_') {
+		ws = append(ws, "contains underscore")
+	}
+	if slices.ContainsFunc(words, func(w string) bool {
+		// a slash may start a URL path, as in 'GET /api/users'
+		return strings.ContainsRune(w, '/') && !strings.HasPrefix(w, "/")
+	}) {
+		ws = append(ws, "contains slash")
+	}
+	// 'go test' ignores a function such as Testfoo, so it's probably a mistake
+	rest := []rune(strings.TrimPrefix(strings.TrimPrefix(name, "Test"), "Fuzz"))
+	if len(rest) > 0 && unicode.IsLower(rest[0]) {
+		ws = append(ws, "starts lowercase")
+	}
+	return ws
+}
+
+// auxiliaries lists the verbs, and the adverbs that often come before them,
+// that don't end in 's', and so aren't recognised by hasVerb's usual rule.
+var auxiliaries = map[string]bool{
+	"always": true,
+	"are":    true,
+	"can":    true,
+	"cannot": true,
+	"could":  true,
+	"do":     true,
+	"have":   true,
+	"may":    true,
+	"must":   true,
+	"never":  true,
+	"should": true,
+	"was":    true,
+	"were":   true,
+	"will":   true,
+	"would":  true,
+}
+
+// hasVerb reports whether the sentence for the test name, not counting any
+// subtests, follows its subject with something that looks like a verb, as in
+// 'Parser returns error' or 'Cache is empty', rather than just naming things,
+// as in 'Parser empty input'. A verb is recognised, heuristically, as a word
+// ending in 's' (but not 'ss'), or one of a few auxiliaries, such as 'can'.
+// A name whose test part is a single word, such as 'TestParse/empty_input',
+// is assumed to describe the behaviour in its subtests, and so reports true.
+func hasVerb(name string) bool {
+	test, _, _ := strings.Cut(name, "/")
+	words := strings.Fields(Prettify(test))
+	if len(words) < 2 {
+		return true
+	}
+	return slices.ContainsFunc(words[1:], func(w string) bool {
+		w = strings.ToLower(w)
+		return auxiliaries[w] || len(w) > 1 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss")
+	})
+}
+
+// initialismSoup reports whether word looks like several initialisms run
+// together, such as 'HTTPJSONAPI', which the reader has to untangle.
+func initialismSoup(word string) bool {
+	upper := 0
+	for _, r := range word {
+		if !unicode.IsUpper(r) {
+			upper = 0
+			continue
+		}
+		upper++
+		if upper > 5 {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint runs 'go test -list' with any extra args supplied by the user, and
+// prints the name of each test that [Check] warns about, with its sentence
+// and the warnings, under the name of its package. Warnings listed in
+// td.LintIgnore are not reported.
+//
+// If there were any warnings, or 'go test' returned some error, td.OK will be
+// false. Errors are reported to td.Stderr.
+func (td *TestDoxer) Lint(userArgs []string) {
+	td.OK = true
+	args := append([]string{"test", "-list", "."}, userArgs...)
+	cmd := exec.Command(td.goCommand(), args...)
+	cmd.Stderr = td.Stderr
+	out, err := cmd.Output()
+	td.lint(out)
+	if err != nil {
+		td.OK = false
+		fmt.Fprintln(td.Stderr, cmd.Args, err)
+	}
+}
+
+// lint reports the warnings about the test names in out, the output of
+// 'go test -list'.
+func (td *TestDoxer) lint(out []byte) {
+	listedTests(out, td.lintPackage)
+}
+
+// listedTests calls fn with the name of each package in out, the output of
+// 'go test -list', and the names of its tests. The names for each package are
+// followed by a summary line, such as 'ok   example.com/foo  0.01s', which
+// gives the package name.
+func listedTests(out []byte, fn func(pkg string, names []string)) {
+	var pending []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) > 1 && (fields[0] == "ok" || fields[0] == "FAIL") {
+			fn(fields[1], pending)
+			pending = nil
+			continue
+		}
+		if strings.HasPrefix(line, "Test") || strings.HasPrefix(line, "Fuzz") {
+			pending = append(pending, line)
+		}
+	}
+}
+
+// lintPackage prints the test names in pkg with warnings, if any.
+func (td *TestDoxer) lintPackage(pkg string, names []string) {
+	header := false
+	for _, name := range names {
+		sentence := td.prettify(name)
+		ws := slices.DeleteFunc(warnings(name, sentence), func(w string) bool {
+			return slices.Contains(td.LintIgnore, w)
+		})
+		if len(ws) == 0 {
+			continue
+		}
+		td.OK = false
+		if !header {
+			fmt.Fprintln(td.Stdout, pkg+":")
+			header = true
+		}
+		fmt.Fprintf(td.Stdout, " %s (%q): %s\n", name, sentence, strings.Join(ws, ", "))
+	}
+	if header {
+		fmt.Fprintln(td.Stdout)
+	}
+}
@@ -0,0 +1,47 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck_ReturnsSentenceAndNoWarningsForGoodNames(t *testing.T) {
+	t.Parallel()
+	want := []gotestdox.Result{
+		{Name: "TestParseJSON_ReturnsError", Sentence: "ParseJSON returns error"},
+		{Name: "FuzzPrettify", Sentence: "[fuzz] Prettify"},
+	}
+	got := gotestdox.Check([]string{"TestParseJSON_ReturnsError", "FuzzPrettify"})
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestCheck_WarnsAboutNamesThatMakePoorSentences(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name string
+		want []string
+	}{
+		{name: "Test", want: []string{"empty sentence"}},
+		{name: "Testfoo", want: []string{"single word", "starts lowercase"}},
+		{name: "ExampleFoo", want: []string{"not a test name"}},
+		{name: "TestParser", want: []string{"single word"}},
+		{name: "TestHTTPJSONAPIWorks", want: []string{"initialism soup"}},
+		{name: "TestRouter/GET_/api/v1/users", want: nil},
+		{name: "TestParserEmptyInput", want: []string{"no verb"}},
+		{name: "TestParserEmptyInput/fails", want: []string{"no verb"}},
+		{name: "TestCacheIsEmptyAtStart", want: nil},
+		{name: "TestParserCanHandleEmptyInput", want: nil},
+		{name: "TestUserStoreSavesUser", want: nil},
+		{name: "TestParse/empty_input", want: nil},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Check([]string{tc.name})[0].Warnings
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%q: %s", tc.name, cmp.Diff(tc.want, got))
+		}
+	}
+}
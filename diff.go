@@ -0,0 +1,84 @@
+package gotestdox
+
+// A RunDiff describes how the results of one test run differ from those of
+// an earlier one, as computed by [Diff]. Each field lists the sentences for
+// the affected tests.
+type RunDiff struct {
+	// NewlyFailing lists the tests that failed in the new run, but didn't
+	// fail in the old one, including tests that weren't there before.
+	NewlyFailing []string
+
+	// Fixed lists the tests that failed in the old run, but passed in the
+	// new one.
+	Fixed []string
+
+	// Disappeared lists the tests that had a result in the old run, but not
+	// in the new one.
+	Disappeared []string
+}
+
+// Diff compares the test results in old and new, which are events such as
+// those produced by 'go test -json', and reports the tests whose status has
+// changed (see [RunDiff]). Events other than test results are ignored, and a
+// test is identified by its package and name, so that renaming a test makes
+// it disappear from the old run and appear in the new one. If a test has
+// more than one result in a run, as when it was retried, the last one
+// counts.
+//
+// Each test is reported by the sentence in its event, or, if that's empty,
+// the result of [Prettify] on its name. Within each list, tests are in the
+// order they first appeared in the run where they were found.
+//
+// This is the basis for a policy of not letting new failures in:
+//
+//	if d := gotestdox.Diff(base, head); len(d.NewlyFailing) > 0 {
+//		...
+//	}
+func Diff(old, new []Event) RunDiff {
+	oldResults, oldOrder := lastResults(old)
+	newResults, newOrder := lastResults(new)
+	d := RunDiff{}
+	for _, key := range newOrder {
+		event := newResults[key]
+		before, existed := oldResults[key]
+		switch {
+		case event.Failed() && !(existed && before.Failed()):
+			d.NewlyFailing = append(d.NewlyFailing, sentenceOf(event))
+		case event.Passed() && existed && before.Failed():
+			d.Fixed = append(d.Fixed, sentenceOf(event))
+		}
+	}
+	for _, key := range oldOrder {
+		if _, ok := newResults[key]; !ok {
+			d.Disappeared = append(d.Disappeared, sentenceOf(oldResults[key]))
+		}
+	}
+	return d
+}
+
+// lastResults returns the last result event for each test in events, and
+// the tests in the order they first appeared.
+func lastResults(events []Event) (map[testKey]Event, []testKey) {
+	results := map[testKey]Event{}
+	order := []testKey{}
+	for _, event := range events {
+		if !event.IsTestResult() && !(event.Skipped() && event.Test != "") {
+			continue
+		}
+		key := testKey{event.Package, event.Test}
+		if _, ok := results[key]; !ok {
+			order = append(order, key)
+		}
+		results[key] = event
+	}
+	return results, order
+}
+
+// sentenceOf returns the sentence for event's test, prettifying its name if
+// the event doesn't already have one.
+func sentenceOf(event Event) string {
+	if event.Sentence != "" {
+		return event.Sentence
+	}
+	return Prettify(event.Test)
+}
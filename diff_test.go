@@ -0,0 +1,99 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff_ReportsNewlyFailingFixedAndDisappearedTests(t *testing.T) {
+	t.Parallel()
+	old := []gotestdox.Event{
+		{Action: "run", Package: "p", Test: "TestStaysPassing"},
+		{Action: "pass", Package: "p", Test: "TestStaysPassing"},
+		{Action: "pass", Package: "p", Test: "TestBreaks"},
+		{Action: "fail", Package: "p", Test: "TestGetsFixed"},
+		{Action: "fail", Package: "p", Test: "TestStaysFailing"},
+		{Action: "pass", Package: "p", Test: "TestIsDeleted"},
+		{Action: "fail", Package: "p"},
+	}
+	new := []gotestdox.Event{
+		{Action: "pass", Package: "p", Test: "TestStaysPassing"},
+		{Action: "fail", Package: "p", Test: "TestBreaks"},
+		{Action: "pass", Package: "p", Test: "TestGetsFixed"},
+		{Action: "fail", Package: "p", Test: "TestStaysFailing"},
+		{Action: "fail", Package: "p", Test: "TestIsNewAndFails"},
+		{Action: "pass", Package: "p", Test: "TestIsNewAndPasses"},
+		{Action: "fail", Package: "p"},
+	}
+	want := gotestdox.RunDiff{
+		NewlyFailing: []string{"Breaks", "Is new and fails"},
+		Fixed:        []string{"Gets fixed"},
+		Disappeared:  []string{"Is deleted"},
+	}
+	got := gotestdox.Diff(old, new)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiff_IdentifiesTestsByPackageAsWellAsName(t *testing.T) {
+	t.Parallel()
+	old := []gotestdox.Event{
+		{Action: "pass", Package: "p", Test: "TestA"},
+	}
+	new := []gotestdox.Event{
+		{Action: "fail", Package: "q", Test: "TestA"},
+	}
+	want := gotestdox.RunDiff{
+		NewlyFailing: []string{"A"},
+		Disappeared:  []string{"A"},
+	}
+	got := gotestdox.Diff(old, new)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiff_UsesLastResultForEachTest(t *testing.T) {
+	t.Parallel()
+	old := []gotestdox.Event{
+		{Action: "fail", Package: "p", Test: "TestFlaky"},
+		{Action: "pass", Package: "p", Test: "TestFlaky"},
+	}
+	new := []gotestdox.Event{
+		{Action: "fail", Package: "p", Test: "TestFlaky"},
+	}
+	want := gotestdox.RunDiff{NewlyFailing: []string{"Flaky"}}
+	got := gotestdox.Diff(old, new)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiff_PrefersSentenceInEventToPrettifiedName(t *testing.T) {
+	t.Parallel()
+	new := []gotestdox.Event{
+		{Action: "fail", Package: "p", Test: "TestA", Sentence: "Custom sentence"},
+	}
+	want := gotestdox.RunDiff{NewlyFailing: []string{"Custom sentence"}}
+	got := gotestdox.Diff(nil, new)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiff_DoesNotReportSkippedTestAsDisappeared(t *testing.T) {
+	t.Parallel()
+	old := []gotestdox.Event{
+		{Action: "pass", Package: "p", Test: "TestA"},
+	}
+	new := []gotestdox.Event{
+		{Action: "skip", Package: "p", Test: "TestA"},
+	}
+	got := gotestdox.Diff(old, new)
+	if !cmp.Equal(gotestdox.RunDiff{}, got) {
+		t.Error(cmp.Diff(gotestdox.RunDiff{}, got))
+	}
+}
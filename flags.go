@@ -0,0 +1,565 @@
+package gotestdox
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FlagSet returns a [*flag.FlagSet] defining gotestdox's own command-line
+// flags, each bound to the corresponding configuration field of td.
+func (td *TestDoxer) FlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("gotestdox", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("number", "number result lines, restarting for each `package`, or counting across the whole run if 'global'", func(s string) error {
+		switch s {
+		case NumberPackage, NumberGlobal:
+			td.Numbering = s
+			return nil
+		}
+		return fmt.Errorf("want %q or %q", NumberPackage, NumberGlobal)
+	})
+	fs.BoolFunc("initialisms", "always render common two-letter words such as 'Ok' and 'Id' as initialisms", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		td.prettifierOption(func(pr *Prettifier) {
+			pr.twoLetterInitialisms = on
+		})
+		return nil
+	})
+	fs.BoolFunc("keep-prefix", "show the 'Test' prefix of each test name as part of its sentence", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		td.prettifierOption(func(pr *Prettifier) {
+			pr.keepPrefix = on
+		})
+		return nil
+	})
+	fs.BoolFunc("strip-testify-suite-prefix", "show testify suite methods such as 'TestMySuite/TestSomething' without the name of the suite", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		td.prettifierOption(func(pr *Prettifier) {
+			pr.stripSuitePrefix = on
+		})
+		return nil
+	})
+	fs.BoolVar(&td.NoPackageHeaders, "no-package-headers", false, "don't print the name of each package before its results")
+	fs.Func("format", "output `format`: 'text', 'json', 'tap', 'markdown', 'junit', 'otlp', 'vtext', or 'html' (default 'text')", func(s string) error {
+		if s == FormatText {
+			td.Formatter = nil
+			return nil
+		}
+		f, err := NewFormatter(s)
+		if err != nil {
+			return err
+		}
+		td.Formatter = f
+		return nil
+	})
+	fs.BoolVar(&td.JSONCompat, "json-compat", false, "print the input from 'go test -json' unchanged, except for adding a 'Sentence' field to each test result")
+	fs.Func("template", "render each result line with this Go `template`, such as '{{.Status}} {{.Test}}'", func(s string) error {
+		tmpl, err := ParseTemplate(s)
+		if err != nil {
+			return err
+		}
+		td.Template = tmpl
+		return nil
+	})
+	fs.StringVar(&td.Highlight, "highlight", "", "emphasise every occurrence of `word` in the printed sentences")
+	fs.Func("fail-under", "succeed as long as at least `percent` of tests pass, instead of failing on any test failure", func(s string) error {
+		rate, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		if rate < 0 || rate > 100 {
+			return errors.New("want a percentage between 0 and 100")
+		}
+		td.FailUnder = rate
+		return nil
+	})
+	fs.Func("proper-nouns", "preserve the capitalisation of these `nouns` (comma-separated) wherever they appear", func(s string) error {
+		if td.overriding("proper-nouns") {
+			td.prettifierOption(func(pr *Prettifier) {
+				pr.properNouns = nil
+			})
+		}
+		td.prettifierOption(WithProperNouns(strings.Split(s, ",")...))
+		return nil
+	})
+	fs.Func("max-words", "shorten any sentence longer than `N` words, ending it with an ellipsis", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return errors.New("want a positive number of words")
+		}
+		td.prettifierOption(WithMaxWords(n))
+		return nil
+	})
+	fs.Func("single-letter-words", "keep these single-letter `words` (comma-separated), such as 'I,T', in upper case in the middle of a sentence", func(s string) error {
+		if td.overriding("single-letter-words") {
+			td.prettifierOption(func(pr *Prettifier) {
+				pr.singleLetterWords = nil
+			})
+		}
+		td.prettifierOption(WithSingleLetterWords(strings.Split(s, ",")...))
+		return nil
+	})
+	fs.Func("color", "colour the output: 'auto', 'always', or 'never' (default 'auto', which colours it only for a terminal)", func(s string) error {
+		switch s {
+		case ColorAuto, ColorAlways, ColorNever:
+			td.Color = s
+			return nil
+		}
+		return fmt.Errorf("want %q, %q, or %q", ColorAuto, ColorAlways, ColorNever)
+	})
+	fs.BoolVar(&td.Hyperlinks, "hyperlinks", false, "make file locations in test output clickable links, in terminals that support them")
+	fs.BoolVar(&td.ShowModules, "show-modules", false, "in a Go workspace with several modules, show each package's module in its header")
+	fs.BoolVar(&td.PackageLinks, "package-links", false, "make package names in headers clickable links to their directories, in terminals that support them")
+	fs.Func("theme", "colour `theme`: 'default', 'dark', 'light', or 'mono'", func(s string) error {
+		theme, err := LookupTheme(s)
+		if err != nil {
+			return err
+		}
+		td.Theme = theme
+		return nil
+	})
+	fs.Func("package-filter", "print results only for packages matching `glob` (which may contain '**'), or which failed", func(s string) error {
+		for _, segment := range strings.Split(s, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return err
+			}
+		}
+		td.PackageFilter = s
+		return nil
+	})
+	fs.Func("elapsed-precision", "show elapsed times to `N` decimal places (default 2), or whole seconds if 0", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > 9 {
+			return errors.New("want a number between 0 and 9")
+		}
+		td.ElapsedPrecision = n
+		if n == 0 {
+			td.ElapsedPrecision = WholeSeconds
+		}
+		return nil
+	})
+	fs.Func("elapsed-unit", "show elapsed times in `unit`: 's' (seconds) or 'ms' (milliseconds) (default 's')", func(s string) error {
+		switch s {
+		case UnitSeconds, UnitMilliseconds:
+			td.ElapsedUnit = s
+			return nil
+		}
+		return fmt.Errorf("want %q or %q", UnitSeconds, UnitMilliseconds)
+	})
+	fs.Func("elapsed-format", "show each test's elapsed time as `format`, where '%s' stands for the time, such as '[%s]' (default '(%s)')", func(s string) error {
+		if strings.Count(s, "%s") != 1 {
+			return errors.New("want exactly one '%s'")
+		}
+		td.ElapsedFormat = s
+		return nil
+	})
+	fs.BoolVar(&td.PackageElapsed, "package-elapsed", false, "show the total elapsed time for each package in its header")
+	fs.Func("exclude", "hide tests whose sentences match `regexp`", func(s string) error {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return err
+		}
+		td.Exclude = re
+		return nil
+	})
+	fs.Func("fail-pattern", "mark as failed any passing test whose output matches `regexp`", func(s string) error {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return err
+		}
+		td.FailPattern = re
+		return nil
+	})
+	fs.BoolVar(&td.ExcludeByName, "exclude-by-name", false, "match -exclude against test names, instead of sentences")
+	fs.BoolVar(&td.ExcludeIgnoresFailures, "exclude-ignores-failures", false, "don't count failures of tests hidden by -exclude")
+	fs.Func("top-slowest", "at the end of the run, list the `N` slowest tests, across all packages", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return errors.New("want a positive number of tests")
+		}
+		td.TopSlowest = n
+		return nil
+	})
+	fs.BoolFunc("no-subtests", "show only top-level tests, hiding subtests", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		td.NoSubtests = on
+		if on {
+			td.OnlySubtests = false
+		}
+		return nil
+	})
+	fs.BoolFunc("only-subtests", "show only subtests, hiding top-level tests", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		td.OnlySubtests = on
+		if on {
+			td.NoSubtests = false
+		}
+		return nil
+	})
+	fs.DurationVar(&td.MinDuration, "min-duration", 0, "hide tests that took less than `duration` to run, such as '10ms'")
+	fs.Func("sort-packages", "print packages in `order`: 'arrival' (as they finish), or 'failures' (most failing tests first, once all have finished)", func(s string) error {
+		switch s {
+		case SortPackagesArrival, SortPackagesFailures:
+			td.SortPackages = s
+			return nil
+		}
+		return fmt.Errorf("want %q or %q", SortPackagesArrival, SortPackagesFailures)
+	})
+	fs.BoolVar(&td.ShowCoverage, "show-coverage", false, "print each package's coverage summary (when testing with -cover)")
+	fs.BoolVar(&td.ShowNames, "show-names", false, "show each test's full name after its sentence")
+	fs.Func("show-output", "print the output of `which` tests beneath their results: 'failed', 'all', or 'none' (default 'failed')", func(s string) error {
+		switch s {
+		case ShowOutputFailed, ShowOutputAll, ShowOutputNone:
+			td.ShowOutput = s
+			return nil
+		}
+		return fmt.Errorf("want %q, %q, or %q", ShowOutputFailed, ShowOutputAll, ShowOutputNone)
+	})
+	fs.BoolVar(&td.Oneline, "oneline", false, "print only a one-line summary of the whole run, such as '✔ 120/120'")
+	fs.IntVar(&td.Retry, "retry", 0, "rerun failed tests up to `N` times, until they pass")
+	fs.Func("lint-ignore", "with -lint, don't report these `warnings` (comma-separated), such as 'single word'", func(s string) error {
+		if td.overriding("lint-ignore") {
+			td.LintIgnore = nil
+		}
+		td.LintIgnore = append(td.LintIgnore, strings.Split(s, ",")...)
+		return nil
+	})
+	fs.BoolVar(&td.EchoCommand, "echo-command", false, "print the 'go test' command line to standard error before running it")
+	fs.Var(onlyChanged{td}, "only-changed", "test only the packages with files changed since `commit` (given as -only-changed=commit), or since HEAD if no commit is given")
+	fs.BoolVar(&td.FailOnGoStderr, "fail-on-go-stderr", false, "treat anything 'go test' writes to standard error, such as a vet warning, as a failure")
+	fs.StringVar(&td.GoCommand, "go", "", "run `command` instead of 'go' to execute the tests (default $GOTESTDOX_GO, or 'go')")
+	fs.BoolVar(&td.Notify, "notify", false, "show a desktop notification when the tests finish")
+	fs.BoolVar(&td.Fold, "fold", false, "summarise each passing package on one line, and show only the failing tests of a failing package")
+	fs.BoolVar(&td.Dots, "dots", false, "show each passing test as a single check mark, and only failing tests in full")
+	fs.BoolVar(&td.Bars, "bars", false, "show a bar on each result line for its elapsed time, relative to the slowest test in the package")
+	fs.BoolVar(&td.Compact, "compact", false, "pack passing results into columns to fit the terminal width")
+	fs.Func("width", "lay out results to fit `N` columns, instead of the detected terminal width", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return errors.New("want a positive number of columns")
+		}
+		td.Width = n
+		return nil
+	})
+	fs.BoolVar(&td.CollapseSubtests, "collapse-subtests", false, "show how many subtests of each test passed on the test's own line, instead of listing them (except failures)")
+	fs.BoolVar(&td.GroupSubjects, "group-subjects", false, "group tests whose sentences begin with the same subject under a subheading")
+	fs.Func("field-map", "read JSON input whose fields have different names, given as `from=to` pairs (comma-separated), such as 'status=Action'", func(s string) error {
+		fields := map[string]string{}
+		for _, pair := range strings.Split(s, ",") {
+			from, to, ok := strings.Cut(pair, "=")
+			if !ok || from == "" || to == "" {
+				return fmt.Errorf("want from=to, got %q", pair)
+			}
+			fields[from] = to
+		}
+		td.Decoder = FieldMapping(fields)
+		return nil
+	})
+	fs.BoolVar(&td.WarnParseErrors, "warn-parse-errors", false, "report each line of input that isn't valid JSON, instead of just how many were ignored")
+	fs.Func("output", "also write results to a file, given as `format=path`, such as 'junit=report.xml' (may be repeated)", func(s string) error {
+		name, path, ok := strings.Cut(s, "=")
+		if !ok || path == "" {
+			return fmt.Errorf("want format=path, got %q", s)
+		}
+		f, err := NewFormatter(name)
+		if err != nil {
+			return err
+		}
+		td.outputFile("output", f, path)
+		return nil
+	})
+	fs.Func("save", "when running the tests, also save the raw output of 'go test -json' to `path`, to be filtered again later", func(s string) error {
+		td.savePath = s
+		return nil
+	})
+	fs.Func("baseline", "mark tests that have no result in the 'go test -json' output saved in `path`, such as with -save, as new", func(s string) error {
+		events, err := readBaseline(s)
+		if err != nil {
+			return err
+		}
+		td.Baseline = events
+		return nil
+	})
+	fs.Func("owners", "at the end, list failing tests again, grouped by their owners according to the CODEOWNERS file at `path`", func(s string) error {
+		ownerOf, err := td.ownersOf(s)
+		if err != nil {
+			return err
+		}
+		td.OwnerOf = ownerOf
+		return nil
+	})
+	fs.Func("fail-summary-limit", "list at most `N` failing tests again at the end, with -owners or -group-failures-by-file (default unlimited)", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return errors.New("want a positive number of tests")
+		}
+		td.FailSummaryLimit = n
+		return nil
+	})
+	fs.BoolVar(&td.GroupFailuresByFile, "group-failures-by-file", false, "at the end, list failing tests again, grouped by the file where each failure was reported")
+	fs.BoolVar(&td.FirstFailureJump, "first-failure-jump", false, "at the end, if any test failed, print to stderr a command to open an editor at the first failure")
+	fs.Func("editor", "with -first-failure-jump, the `template` for the editor command, such as 'code -g {{.File}}:{{.Line}}' (default "+strconv.Quote(DefaultEditorCommand)+")", func(s string) error {
+		tmpl, err := ParseEditorCommand(s)
+		if err != nil {
+			return err
+		}
+		td.EditorCommand = tmpl
+		return nil
+	})
+	fs.Func("html", "also write results to `path` as a self-contained HTML page", func(s string) error {
+		td.outputFile("html", &HTMLFormatter{}, s)
+		return nil
+	})
+	fs.Func("passthrough-vtext", "also write results to `path` in the text format of 'go test -v', for tools that expect it", func(s string) error {
+		td.outputFile("passthrough-vtext", &VTextFormatter{}, s)
+		return nil
+	})
+	fs.Func("otel", "send each result as an OpenTelemetry span to the OTLP/HTTP collector at `endpoint`", func(s string) error {
+		exporter, err := NewOTLPExporter(s, td.Stderr)
+		if err != nil {
+			return err
+		}
+		if td.overriding("otel") {
+			td.dropPendingOutputs("otel")
+		}
+		td.pendingOutputs = append(td.pendingOutputs, pendingOutput{
+			flag:   "otel",
+			output: Output{Formatter: &OTLPFormatter{}, Writer: exporter},
+		})
+		return nil
+	})
+	return fs
+}
+
+// A pendingOutput is an [Output] requested by the flag named flag, to be added
+// to td.Outputs by [TestDoxer.OpenFiles]. If path is set, the output's Writer
+// is the file at path, which is created then.
+type pendingOutput struct {
+	flag   string
+	output Output
+	path   string
+}
+
+// outputFile records that the results formatted by f are to be written to
+// the file at path, as requested by the flag named flag, once
+// [TestDoxer.OpenFiles] creates it.
+func (td *TestDoxer) outputFile(flag string, f Formatter, path string) {
+	if td.overriding(flag) {
+		td.dropPendingOutputs(flag)
+	}
+	td.pendingOutputs = append(td.pendingOutputs, pendingOutput{
+		flag:   flag,
+		output: Output{Formatter: f},
+		path:   path,
+	})
+}
+
+// dropPendingOutputs forgets any outputs requested by the flag named flag.
+func (td *TestDoxer) dropPendingOutputs(flag string) {
+	td.pendingOutputs = slices.DeleteFunc(td.pendingOutputs, func(o pendingOutput) bool {
+		return o.flag == flag
+	})
+}
+
+// OpenFiles creates the files named by flags such as -save and -output, which
+// the [flag.FlagSet] returned by [TestDoxer.FlagSet] only records, so that
+// nothing is created unless all the flags are valid, and that a flag given
+// both as a default and on the command line creates only one file. The
+// resulting outputs are added to td.Outputs, and td.Save. OpenFiles returns a
+// function that closes the files, to be called when the run is finished. If
+// any file can't be created, those already created are closed, and OpenFiles
+// returns the error.
+func (td *TestDoxer) OpenFiles() (closeFiles func() error, err error) {
+	var files []*os.File
+	closeFiles = func() error {
+		var errs []error
+		for _, f := range files {
+			errs = append(errs, f.Close())
+		}
+		return errors.Join(errs...)
+	}
+	create := func(path string) (*os.File, error) {
+		f, err := os.Create(path)
+		if err != nil {
+			closeFiles()
+			return nil, err
+		}
+		files = append(files, f)
+		return f, nil
+	}
+	for _, o := range td.pendingOutputs {
+		if o.path != "" {
+			f, err := create(o.path)
+			if err != nil {
+				return nil, err
+			}
+			o.output.Writer = f
+		}
+		td.Outputs = append(td.Outputs, o.output)
+	}
+	td.pendingOutputs = nil
+	if td.savePath != "" {
+		f, err := create(td.savePath)
+		if err != nil {
+			return nil, err
+		}
+		td.Save = f
+		td.savePath = ""
+	}
+	return closeFiles, nil
+}
+
+// overriding reports whether the repeatable flag named name is being given
+// for the first time since the default flags were applied (see
+// parseDefaults), and had a default value, which the new values should
+// replace, rather than add to.
+func (td *TestDoxer) overriding(name string) bool {
+	if !td.defaulted[name] {
+		return false
+	}
+	delete(td.defaulted, name)
+	return true
+}
+
+// prettifierOption applies opt to td's Prettifier, creating one if necessary.
+func (td *TestDoxer) prettifierOption(opt Option) {
+	if td.Prettifier == nil {
+		td.Prettifier = NewPrettifier()
+	}
+	opt(td.Prettifier)
+}
+
+// ParseArgs separates the command-line arguments intended for gotestdox itself
+// from those to be passed on to 'go test'. Any flag defined in fs is parsed and
+// applied; all other arguments are returned unchanged, in their original order.
+//
+// Flags may be given as '-name value', '-name=value', or, for boolean flags,
+// just '-name'. Everything following an '-args' argument is passed through
+// as-is, since 'go test' hands it on to the test binary.
+func ParseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	userArgs := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-args" || arg == "--args" {
+			return append(userArgs, args[i:]...), nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			userArgs = append(userArgs, arg)
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		name, value, hasValue := strings.Cut(name, "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			userArgs = append(userArgs, arg)
+			continue
+		}
+		if !hasValue {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+				value = "true"
+			} else {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("flag needs an argument: -%s", name)
+				}
+				i++
+				value = args[i]
+			}
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag -%s: %w", value, name, err)
+		}
+	}
+	return userArgs, nil
+}
+
+// DefaultsFile is the name of the file, in the current directory, from which
+// [Main] reads default flags (see [DefaultFlags]).
+const DefaultsFile = ".gotestdox"
+
+// DefaultFlags returns the default flags that [Main] applies before those on
+// the command line: first any from the file [DefaultsFile] in dir, then any
+// from the GOTESTDOX_FLAGS environment variable. Since flags are applied in
+// order, a flag given in the environment overrides the same flag in the file,
+// and a flag given on the command line overrides both.
+//
+// In both places, flags are separated by whitespace, so a value containing
+// spaces can't be given. In the file, lines starting with '#' are comments.
+// It's not an error for the file not to exist.
+func DefaultFlags(dir string) ([]string, error) {
+	args := []string{}
+	data, err := os.ReadFile(filepath.Join(dir, DefaultsFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		args = append(args, strings.Fields(line)...)
+	}
+	return append(args, strings.Fields(os.Getenv("GOTESTDOX_FLAGS"))...), nil
+}
+
+// parseDefaults applies the flags returned by [DefaultFlags] for the current
+// directory to fs, which must be td's [TestDoxer.FlagSet]. Only gotestdox's
+// own flags are allowed as defaults, since arguments for 'go test' would be
+// mistaken for package names or files. If a flag that may be repeated, such
+// as -proper-nouns, is given again later, its values replace the defaults.
+func (td *TestDoxer) parseDefaults(fs *flag.FlagSet) error {
+	defaults, err := DefaultFlags(".")
+	if err != nil {
+		return err
+	}
+	extra, err := ParseArgs(fs, defaults)
+	if err != nil {
+		return fmt.Errorf("default flags: %w", err)
+	}
+	if len(extra) > 0 {
+		return fmt.Errorf("default flags: %q is not a gotestdox flag", extra[0])
+	}
+	td.defaulted = map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		td.defaulted[f.Name] = true
+	})
+	return nil
+}
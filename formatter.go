@@ -0,0 +1,952 @@
+package gotestdox
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// A Formatter renders test results for display. [TestDoxer.Filter] calls
+// Header with the package result event when it starts printing the results
+// for a package, then Format for each test result in the package, and finally
+// Footer with the same package result event. The data returned by each method
+// is written to the TestDoxer's Stdout stream.
+//
+// For a test result event, the Output field contains all the output produced
+// by that test. For a package result event, it contains the package's output
+// that isn't associated with any particular test, such as its coverage
+// summary.
+type Formatter interface {
+	Header(pkg Event) ([]byte, error)
+	Format(test Event) ([]byte, error)
+	Footer(pkg Event) ([]byte, error)
+}
+
+// A Finisher is a [Formatter] that needs to produce some final output once all
+// results have been formatted, such as a closing tag or a summary.
+type Finisher interface {
+	Finish() ([]byte, error)
+}
+
+// Names of the output formats understood by [NewFormatter].
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatTAP      = "tap"
+	FormatMarkdown = "markdown"
+	FormatJUnit    = "junit"
+	FormatOTLP     = "otlp"
+	FormatVText    = "vtext"
+	FormatHTML     = "html"
+)
+
+// NewFormatter returns a new [Formatter] for the named output format, or an
+// error if there is no such format.
+func NewFormatter(name string) (Formatter, error) {
+	switch name {
+	case FormatText:
+		return &TextFormatter{}, nil
+	case FormatJSON:
+		return &JSONFormatter{}, nil
+	case FormatTAP:
+		return &TAPFormatter{}, nil
+	case FormatMarkdown:
+		return &MarkdownFormatter{}, nil
+	case FormatJUnit:
+		return &JUnitFormatter{}, nil
+	case FormatOTLP:
+		return &OTLPFormatter{}, nil
+	case FormatVText:
+		return &VTextFormatter{}, nil
+	case FormatHTML:
+		return &HTMLFormatter{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", name)
+}
+
+// TextFormatter is the default [Formatter], which produces the familiar
+// gotestdox output: the name of each package, followed by a line for each
+// test, as produced by [Event.String]. The output of any failing test is
+// shown beneath it.
+type TextFormatter struct {
+	// Numbering, NoPackageHeaders, PackageElapsed, ShowCoverage, Highlight,
+	// and Theme have the same meanings as the corresponding fields on
+	// [TestDoxer].
+	Numbering        string
+	NoPackageHeaders bool
+	PackageElapsed   bool
+	ShowCoverage     bool
+	Highlight        string
+	Theme            Theme
+
+	// GroupSubjects has the same meaning as the corresponding field on
+	// [TestDoxer]. When it is set, results are buffered until the end of each
+	// package, and printed by Footer.
+	GroupSubjects bool
+
+	// CollapseSubtests has the same meaning as the corresponding field on
+	// [TestDoxer]. Like GroupSubjects, which it overrides, it buffers the
+	// results until the end of each package.
+	CollapseSubtests bool
+
+	// Width, if non-zero, selects a compact layout, in which passing results
+	// with short sentences are packed into columns to fit this many
+	// characters per line. Failures and long sentences are still printed on
+	// a line of their own. Width is ignored if GroupSubjects is set.
+	Width int
+
+	// ShowNames has the same meaning as the corresponding field on
+	// [TestDoxer]. Since the compact layout has no room for names, Width is
+	// ignored if ShowNames is set.
+	ShowNames bool
+
+	// ShowOutput determines which tests have their output printed beneath
+	// the result line: [ShowOutputFailed] (the default, if ShowOutput is
+	// empty), [ShowOutputAll], or [ShowOutputNone].
+	ShowOutput string
+
+	// ElapsedPrecision, ElapsedUnit, and ElapsedFormat have the same
+	// meanings as the corresponding fields on [TestDoxer].
+	ElapsedPrecision int
+	ElapsedUnit      string
+	ElapsedFormat    string
+
+	// Fold summarises each passing package on a single line, giving the
+	// number of tests and the elapsed time, instead of listing its tests. For
+	// a failing package, only the failing tests are shown.
+	Fold bool
+
+	// Dots shows each passing test as a single check mark, with no sentence,
+	// so that consecutive passes form a compact row. Failing tests are shown
+	// in full, as usual.
+	Dots bool
+
+	// Bars adds a bar to the end of each result line, whose length shows
+	// the test's elapsed time relative to the slowest test in its package.
+	// Like GroupSubjects, it buffers the results until the end of each
+	// package.
+	Bars bool
+
+	// Template, if set, renders each result line, instead of the default
+	// format (see [DefaultTemplate]). It is executed with a [TemplateData]
+	// for the test.
+	Template *template.Template
+
+	// PackageDir, if set, makes each file location in test output, such as
+	// "parser_test.go:12", a hyperlink to the file (see [Hyperlink]).
+	// Relative filenames are resolved against the directory that PackageDir
+	// returns for the test's package.
+	PackageDir func(pkg string) string
+
+	// PackageLink, if set, makes the package name in each header a
+	// hyperlink to the directory that PackageLink returns for it, unless
+	// that's empty.
+	PackageLink func(pkg string) string
+
+	// ModuleOf, if set, prefixes the package name in each header with the
+	// module path that ModuleOf returns for it, in square brackets, unless
+	// that's empty.
+	ModuleOf func(pkg string) string
+
+	count   int
+	slowest float64
+	pending []Event
+	cells   []Event
+	folded  int
+	dots    int
+	err     error
+
+	// highlightRE matches the word highlighted, for f.highlight
+	highlightRE *regexp.Regexp
+	highlighted string
+}
+
+// DefaultTemplate is a template for [TextFormatter.Template] that produces
+// the same result lines as the default format, with the elapsed time to two
+// decimal places.
+const DefaultTemplate = ` {{.Status}} {{.Sentence}} ({{printf "%.2f" .Elapsed}}s)` +
+	`{{with .Category}} [{{.}}]{{end}}` +
+	`{{if .Retry}} (flaky, passed on retry {{.Retry}}){{end}}` +
+	`{{if .New}} (new){{end}}`
+
+// TemplateData is the data given to [TextFormatter.Template] for each test:
+// all the fields of the [Event], plus its Status, which is the check mark or
+// x that would be shown for it, in the appropriate colour.
+type TemplateData struct {
+	Event
+	Status string
+}
+
+// ParseTemplate parses text as a template for [TextFormatter.Template], and
+// checks that it can be executed with a [TemplateData], returning an error if
+// not.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("line").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, TemplateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Header prints the name of the package, unless f.NoPackageHeaders is set,
+// followed by its total elapsed time if f.PackageElapsed is set. If f.Fold is
+// set and the package passed, the header is printed by Footer instead. If the
+// package was skipped, because all its tests were skipped, or no tests ran,
+// because none matched the '-run' pattern, Header says so, whatever the
+// settings, so that this doesn't go unnoticed.
+func (f *TextFormatter) Header(pkg Event) ([]byte, error) {
+	if f.Numbering == NumberPackage {
+		f.count = 0
+	}
+	f.folded = 0
+	if NoTests(pkg.Output) == NoTestsToRun {
+		return []byte(f.packageName(pkg) + ": " + f.theme().paint(RoleSkip, "no tests to run") + "\n"), nil
+	}
+	if f.Fold && pkg.Passed() {
+		// printed by Footer, once we know how many tests there are
+		return nil, nil
+	}
+	if pkg.Skipped() {
+		return []byte(f.packageName(pkg) + ": " + f.theme().paint(RoleSkip, "all tests skipped") + "\n"), nil
+	}
+	if f.NoPackageHeaders {
+		return nil, nil
+	}
+	if f.PackageElapsed {
+		return []byte(fmt.Sprintf("%s (%s):\n", f.packageName(pkg), f.elapsed().value(pkg.Elapsed))), nil
+	}
+	return []byte(f.packageName(pkg) + ":\n"), nil
+}
+
+// packageName returns the name of pkg's package, as a hyperlink to its
+// directory if f.PackageLink is set, and preceded by its module if
+// f.ModuleOf is set.
+func (f *TextFormatter) packageName(pkg Event) string {
+	name := pkg.Package
+	if f.PackageLink != nil {
+		name = linkDir(f.PackageLink(pkg.Package), name)
+	}
+	if f.ModuleOf != nil {
+		if module := f.ModuleOf(pkg.Package); module != "" {
+			name = "[" + module + "] " + name
+		}
+	}
+	return name
+}
+
+// Format prints the test result, and any output from the test, as selected by
+// f.ShowOutput.
+func (f *TextFormatter) Format(test Event) ([]byte, error) {
+	if f.Fold && !test.Failed() {
+		f.folded++
+		return nil, nil
+	}
+	if f.Dots {
+		if !test.Failed() {
+			f.dots++
+			return nil, nil
+		}
+		buf := &bytes.Buffer{}
+		f.formatDots(buf)
+		f.format(buf, test, "")
+		return buf.Bytes(), f.takeErr()
+	}
+	if f.GroupSubjects || f.CollapseSubtests || f.Bars {
+		f.pending = append(f.pending, test)
+		return nil, nil
+	}
+	if f.Width > 0 && !f.ShowNames && test.Passed() && !f.showsOutput(test) && f.cellWidth(test) < f.Width/2 {
+		f.cells = append(f.cells, test)
+		return nil, nil
+	}
+	buf := &bytes.Buffer{}
+	f.formatCells(buf)
+	f.format(buf, test, "")
+	return buf.Bytes(), f.takeErr()
+}
+
+func (f *TextFormatter) format(buf *bytes.Buffer, test Event, indent string) {
+	f.number(buf)
+	if f.Highlight != "" {
+		test.Sentence = f.highlight(test.Sentence)
+	}
+	line := test.render(f.theme(), f.elapsed())
+	if f.Template != nil {
+		line = f.execute(test)
+	}
+	if f.ShowNames && test.Test != "" {
+		line += " (" + test.Test + ")"
+	}
+	if f.Bars {
+		if bar := elapsedBar(test.Elapsed, f.slowest); bar != "" {
+			line += " " + f.theme().paint(RoleSlow, bar)
+		}
+	}
+	fmt.Fprintln(buf, indent+line)
+	if f.showsOutput(test) {
+		buf.WriteString(f.output(test))
+	}
+}
+
+// execute renders the result line for test using f.Template. If this fails,
+// the error is recorded, to be returned by the current Format or Footer call.
+func (f *TextFormatter) execute(test Event) string {
+	status := f.theme().paint(RoleFail, "x")
+	if test.Passed() {
+		status = f.theme().paint(RolePass, "✔")
+	}
+	line := &strings.Builder{}
+	err := f.Template.Execute(line, TemplateData{Event: test, Status: status})
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return line.String()
+}
+
+// takeErr returns any error recorded by execute, and clears it.
+func (f *TextFormatter) takeErr() error {
+	err := f.err
+	f.err = nil
+	return err
+}
+
+// number prints the next sequence number, if f.Numbering is set.
+func (f *TextFormatter) number(buf *bytes.Buffer) {
+	if f.Numbering != "" {
+		f.count++
+		fmt.Fprintf(buf, "%4d", f.count)
+	}
+}
+
+// Values for [TextFormatter.ShowOutput].
+const (
+	ShowOutputFailed = "failed"
+	ShowOutputAll    = "all"
+	ShowOutputNone   = "none"
+)
+
+// showsOutput reports whether any output from test should be printed,
+// according to f.ShowOutput.
+func (f *TextFormatter) showsOutput(test Event) bool {
+	if test.Output == "" {
+		return false
+	}
+	switch f.ShowOutput {
+	case ShowOutputAll:
+		return true
+	case ShowOutputNone:
+		return false
+	}
+	return test.Failed()
+}
+
+// output returns the output of test, indented by indentOutput, and with file
+// locations made into hyperlinks if f.PackageDir is set.
+func (f *TextFormatter) output(test Event) string {
+	output := indentOutput(test.Output)
+	if f.PackageDir != nil {
+		output = linkLocations(output, f.PackageDir(test.Package))
+	}
+	return output
+}
+
+// indentOutput indents any lines of test output that aren't already indented
+// (such as those printed directly to standard output by the test), to line
+// up with the messages logged by [testing.T], which 'go test' indents by four
+// spaces.
+func indentOutput(output string) string {
+	lines := strings.SplitAfter(output, "\n")
+	for i, line := range lines {
+		if line != "" && line != "\n" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			lines[i] = "    " + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// elapsed returns the style in which to show elapsed times, according to
+// f.ElapsedPrecision, f.ElapsedUnit, and f.ElapsedFormat.
+func (f *TextFormatter) elapsed() elapsedStyle {
+	style := elapsedStyle{precision: f.ElapsedPrecision, unit: f.ElapsedUnit, format: f.ElapsedFormat}
+	switch {
+	case f.ElapsedPrecision == 0 && f.ElapsedUnit == UnitMilliseconds:
+		style.precision = 0
+	case f.ElapsedPrecision == 0:
+		style.precision = 2
+	case f.ElapsedPrecision < 0:
+		style.precision = 0
+	}
+	return style
+}
+
+func (f *TextFormatter) theme() Theme {
+	if f.Theme == nil {
+		return DefaultTheme
+	}
+	return f.Theme
+}
+
+// cellWidth returns the number of characters needed to show test in the
+// compact layout, as the status symbol and sentence, without the elapsed time.
+func (f *TextFormatter) cellWidth(test Event) int {
+	width := 3 + utf8.RuneCountInString(test.Sentence)
+	if f.Numbering != "" {
+		width += 4
+	}
+	return width
+}
+
+// formatCells prints any passing results buffered by the compact layout, in
+// as many equal-width columns as will fit in f.Width.
+func (f *TextFormatter) formatCells(buf *bytes.Buffer) {
+	if len(f.cells) == 0 {
+		return
+	}
+	colWidth := 0
+	for _, test := range f.cells {
+		colWidth = max(colWidth, f.cellWidth(test)+1)
+	}
+	cols := max(f.Width/colWidth, 1)
+	for i, test := range f.cells {
+		if f.Numbering != "" {
+			f.count++
+			fmt.Fprintf(buf, "%4d", f.count)
+		}
+		sentence := test.Sentence
+		if f.Highlight != "" {
+			sentence = f.highlight(sentence)
+		}
+		fmt.Fprintf(buf, " %s %s", f.theme().paint(RolePass, "✔"), sentence)
+		if i%cols == cols-1 || i == len(f.cells)-1 {
+			buf.WriteString("\n")
+			continue
+		}
+		buf.WriteString(strings.Repeat(" ", colWidth-f.cellWidth(test)))
+	}
+	f.cells = nil
+}
+
+// Footer prints any results buffered by f.Dots, f.GroupSubjects, f.Bars, or
+// f.Width, and the package's coverage summary, if f.ShowCoverage is set and
+// there is one, followed by a blank line to separate this package from the
+// next. If f.Fold is set and the package passed, it prints the one-line
+// summary instead.
+func (f *TextFormatter) Footer(pkg Event) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if f.Fold && pkg.Passed() && NoTests(pkg.Output) == "" {
+		tests := "tests"
+		if f.folded == 1 {
+			tests = "test"
+		}
+		fmt.Fprintf(buf, "%s %s (%d %s, %s)\n", f.packageName(pkg), f.theme().paint(RolePass, "✔"), f.folded, tests, f.elapsed().value(pkg.Elapsed))
+		return buf.Bytes(), nil
+	}
+	f.slowest = 0
+	for _, test := range f.pending {
+		f.slowest = max(f.slowest, test.Elapsed)
+	}
+	switch {
+	case f.CollapseSubtests:
+		f.formatCollapsed(buf)
+	case f.GroupSubjects:
+		f.formatGroups(buf)
+	case f.Bars:
+		for _, test := range f.pending {
+			f.format(buf, test, "")
+		}
+		f.pending = nil
+	}
+	f.formatCells(buf)
+	f.formatDots(buf)
+	if f.ShowCoverage {
+		if coverage := Coverage(pkg.Output); coverage != "" {
+			buf.WriteString(" coverage: " + coverage + " of statements\n")
+		}
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), f.takeErr()
+}
+
+// barWidth is the length, in characters, of the bar shown by
+// [TextFormatter.Bars] for the slowest test in a package.
+const barWidth = 10
+
+// elapsedBar returns a bar of block characters whose length is proportional to
+// elapsed, as a fraction of slowest, to the nearest eighth of a character. A
+// test that took no measurable time gets no bar.
+func elapsedBar(elapsed, slowest float64) string {
+	if slowest <= 0 {
+		return ""
+	}
+	eighths := int(math.Round(elapsed / slowest * barWidth * 8))
+	partial := []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+	return strings.Repeat("█", eighths/8) + partial[eighths%8]
+}
+
+// formatDots prints a check mark for each passing test counted since the last
+// call, all on one line.
+func (f *TextFormatter) formatDots(buf *bytes.Buffer) {
+	if f.dots == 0 {
+		return
+	}
+	if f.Numbering != "" {
+		f.count += f.dots
+	}
+	fmt.Fprintf(buf, " %s\n", f.theme().paint(RolePass, strings.Repeat("✔", f.dots)))
+	f.dots = 0
+}
+
+// formatCollapsed prints the pending results, replacing the subtests of each
+// test with a count of how many of them passed, on the test's own line. Any
+// failing subtests are still printed in full, beneath their parent. Subtests
+// whose parent isn't among the results are printed as usual.
+func (f *TextFormatter) formatCollapsed(buf *bytes.Buffer) {
+	tests := f.pending
+	f.pending = nil
+	parents := map[string]bool{}
+	for _, test := range tests {
+		if !strings.Contains(test.Test, "/") {
+			parents[test.Test] = true
+		}
+	}
+	for _, test := range tests {
+		parent, _, isSubtest := strings.Cut(test.Test, "/")
+		if isSubtest && parents[parent] {
+			// printed with its parent
+			continue
+		}
+		var subtests, failed []Event
+		for _, sub := range tests {
+			if !isSubtest && strings.HasPrefix(sub.Test, test.Test+"/") {
+				subtests = append(subtests, sub)
+				if sub.Failed() {
+					failed = append(failed, sub)
+				}
+			}
+		}
+		if len(subtests) == 0 {
+			f.format(buf, test, "")
+			continue
+		}
+		status := f.theme().paint(RoleFail, "x")
+		if test.Passed() {
+			status = f.theme().paint(RolePass, "✔")
+		}
+		if f.Highlight != "" {
+			test.Sentence = f.highlight(test.Sentence)
+		}
+		f.number(buf)
+		fmt.Fprintf(buf, " %s %s (%d/%d subtests, %s)\n", status, test.Sentence, len(subtests)-len(failed), len(subtests), f.elapsed().value(test.Elapsed))
+		if f.showsOutput(test) {
+			buf.WriteString(f.output(test))
+		}
+		for _, sub := range failed {
+			f.format(buf, sub, "  ")
+		}
+	}
+}
+
+// formatGroups prints the pending results, grouping together consecutive
+// tests whose sentences begin with the same word (and don't consist only of
+// that word). Each such group is printed
+// under a subheading giving the words its sentences have in common (the
+// subject), which are omitted from the indented results beneath it.
+func (f *TextFormatter) formatGroups(buf *bytes.Buffer) {
+	tests := f.pending
+	f.pending = nil
+	for len(tests) > 0 {
+		first := strings.Fields(tests[0].Sentence)
+		n := 1
+		for n < len(tests) && len(first) > 1 {
+			words := strings.Fields(tests[n].Sentence)
+			if len(words) < 2 || words[0] != first[0] {
+				break
+			}
+			n++
+		}
+		group := tests[:n]
+		tests = tests[n:]
+		if n < 2 {
+			for _, test := range group {
+				f.format(buf, test, "")
+			}
+			continue
+		}
+		subject := commonSubject(group)
+		fmt.Fprintf(buf, " %s:\n", strings.Join(subject, " "))
+		for _, test := range group {
+			test.Sentence = strings.Join(strings.Fields(test.Sentence)[len(subject):], " ")
+			f.format(buf, test, "  ")
+		}
+	}
+}
+
+// commonSubject returns the longest sequence of leading words shared by the
+// sentences of all the given tests, always leaving at least one word of each
+// sentence remaining.
+func commonSubject(tests []Event) []string {
+	subject := strings.Fields(tests[0].Sentence)
+	for _, test := range tests {
+		words := strings.Fields(test.Sentence)
+		if len(subject) > len(words)-1 {
+			subject = subject[:max(len(words)-1, 0)]
+		}
+		for i := range subject {
+			if subject[i] != words[i] {
+				subject = subject[:i]
+				break
+			}
+		}
+	}
+	return subject
+}
+
+// Reasons, returned by [NoTests], why a package had no test results.
+const (
+	NoTestFiles  = "no test files"
+	NoTestsToRun = "no tests to run"
+)
+
+// NoTests returns the reason given in the package output, if 'go test'
+// reported that the package had no tests: [NoTestFiles], if it has no test
+// files at all, or [NoTestsToRun], if it has tests, but none of them matched
+// the '-run' pattern. Otherwise, it returns the empty string.
+func NoTests(output string) string {
+	for _, reason := range []string{NoTestFiles, NoTestsToRun} {
+		if strings.Contains(output, "["+reason+"]") {
+			return reason
+		}
+	}
+	return ""
+}
+
+var coverageRE = regexp.MustCompile(`coverage: (\d+(?:\.\d+)?%) of statements`)
+
+// Coverage returns the percentage of statements covered, such as "85.0%", as
+// reported in the given package output, or the empty string if there is no
+// coverage summary.
+func Coverage(output string) string {
+	m := coverageRE.FindStringSubmatch(output)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// highlight returns sentence with every occurrence of f.Highlight (as a whole
+// word, ignoring case) shown in bold and underlined. If colour is disabled,
+// the sentence is returned unchanged. The pattern is compiled the first time
+// it's needed, and again only if f.Highlight changes.
+func (f *TextFormatter) highlight(sentence string) string {
+	if color.NoColor {
+		return sentence
+	}
+	if f.highlightRE == nil || f.highlighted != f.Highlight {
+		f.highlightRE = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(f.Highlight) + `\b`)
+		f.highlighted = f.Highlight
+	}
+	style := color.New(color.Bold, color.Underline)
+	return f.highlightRE.ReplaceAllStringFunc(sentence, func(match string) string {
+		return style.Sprint(match)
+	})
+}
+
+// OnelineFormatter prints nothing but a single line summarising the whole
+// run, once all results have been seen: for example, "✔ 120/120" if all tests
+// passed, or "x 3 failing" if not. This is suitable for capturing in a shell
+// prompt or status bar.
+type OnelineFormatter struct {
+	Theme Theme
+
+	passed, failed, broken int
+	pkgFailed              bool
+}
+
+// Header prints nothing.
+func (f *OnelineFormatter) Header(pkg Event) ([]byte, error) {
+	f.pkgFailed = false
+	return nil, nil
+}
+
+// Format counts the test result, and prints nothing.
+func (f *OnelineFormatter) Format(test Event) ([]byte, error) {
+	if test.Failed() {
+		f.failed++
+		f.pkgFailed = true
+	} else {
+		f.passed++
+	}
+	return nil, nil
+}
+
+// Footer counts the package if it failed without any failing tests (for
+// example, because it didn't compile), and prints nothing.
+func (f *OnelineFormatter) Footer(pkg Event) ([]byte, error) {
+	if pkg.Failed() && !f.pkgFailed {
+		f.broken++
+	}
+	return nil, nil
+}
+
+// Finish prints the summary line.
+func (f *OnelineFormatter) Finish() ([]byte, error) {
+	theme := f.Theme
+	if theme == nil {
+		theme = DefaultTheme
+	}
+	switch {
+	case f.failed > 0:
+		return []byte(fmt.Sprintf("%s %d failing\n", theme.paint(RoleFail, "x"), f.failed)), nil
+	case f.broken == 1:
+		return []byte(theme.paint(RoleFail, "x") + " 1 package failing\n"), nil
+	case f.broken > 0:
+		return []byte(fmt.Sprintf("%s %d packages failing\n", theme.paint(RoleFail, "x"), f.broken)), nil
+	}
+	total := f.passed + f.failed
+	return []byte(fmt.Sprintf("%s %d/%d\n", theme.paint(RolePass, "✔"), f.passed, total)), nil
+}
+
+// JSONFormatter prints each test result as a single line of JSON, including
+// its prettified Sentence, suitable for processing by other programs.
+type JSONFormatter struct{}
+
+// Header prints nothing.
+func (f *JSONFormatter) Header(pkg Event) ([]byte, error) {
+	return nil, nil
+}
+
+// Format prints the test result as JSON.
+func (f *JSONFormatter) Format(test Event) ([]byte, error) {
+	data, err := json.Marshal(test)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Footer prints nothing.
+func (f *JSONFormatter) Footer(pkg Event) ([]byte, error) {
+	return nil, nil
+}
+
+// TAPFormatter prints results in the Test Anything Protocol format, version 13.
+// Each package is introduced by a comment line giving its name, and any
+// output from failing tests is shown as comment lines.
+type TAPFormatter struct {
+	started bool
+	count   int
+}
+
+func (f *TAPFormatter) start() string {
+	if f.started {
+		return ""
+	}
+	f.started = true
+	return "TAP version 13\n"
+}
+
+// Header prints the TAP version line, if this is the first package, followed
+// by a comment naming the package.
+func (f *TAPFormatter) Header(pkg Event) ([]byte, error) {
+	return []byte(f.start() + "# " + pkg.Package + "\n"), nil
+}
+
+// Format prints a TAP test line for the test result.
+func (f *TAPFormatter) Format(test Event) ([]byte, error) {
+	f.count++
+	status := "ok"
+	if test.Failed() {
+		status = "not ok"
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s %d - %s\n", status, f.count, test.Sentence)
+	if test.Failed() {
+		for _, line := range strings.SplitAfter(test.Output, "\n") {
+			if line != "" {
+				fmt.Fprint(buf, "# ", line)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Footer prints nothing.
+func (f *TAPFormatter) Footer(pkg Event) ([]byte, error) {
+	return nil, nil
+}
+
+// Finish prints the TAP plan line, giving the total number of tests.
+func (f *TAPFormatter) Finish() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s1..%d\n", f.start(), f.count)), nil
+}
+
+// MarkdownFormatter prints results as a Markdown document, with a heading for
+// each package and a list item for each test. The output of any failing test
+// is shown in a code block beneath it.
+type MarkdownFormatter struct{}
+
+// Header prints a heading naming the package.
+func (f *MarkdownFormatter) Header(pkg Event) ([]byte, error) {
+	return []byte("## " + pkg.Package + "\n\n"), nil
+}
+
+// Format prints a list item for the test result.
+func (f *MarkdownFormatter) Format(test Event) ([]byte, error) {
+	status := "x"
+	if test.Passed() {
+		status = "✔"
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "- %s %s (%.2fs)\n", status, test.Sentence, test.Elapsed)
+	if test.Failed() && test.Output != "" {
+		fmt.Fprint(buf, "\n  ```\n")
+		for _, line := range strings.SplitAfter(test.Output, "\n") {
+			if line != "" {
+				fmt.Fprint(buf, "  ", line)
+			}
+		}
+		fmt.Fprint(buf, "  ```\n\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// Footer prints a blank line to separate this package from the next.
+func (f *MarkdownFormatter) Footer(pkg Event) ([]byte, error) {
+	return []byte("\n"), nil
+}
+
+// VTextFormatter prints results in the plain text format of 'go test -v',
+// reconstructing the "--- PASS" or "--- FAIL" line for each test from its
+// result, for the benefit of tools that can only read that format. Tests are
+// named as they are in the code, not by their sentences.
+type VTextFormatter struct{}
+
+// Header prints nothing.
+func (f *VTextFormatter) Header(pkg Event) ([]byte, error) {
+	return nil, nil
+}
+
+// Format prints the result line for the test, indented according to its
+// depth as a subtest, followed by its output if it failed.
+func (f *VTextFormatter) Format(test Event) ([]byte, error) {
+	status := "FAIL"
+	if test.Passed() {
+		status = "PASS"
+	}
+	indent := strings.Repeat("    ", strings.Count(test.Test, "/"))
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s--- %s: %s (%.2fs)\n", indent, status, test.Test, test.Elapsed)
+	if test.Failed() {
+		fmt.Fprint(buf, indentOutput(test.Output))
+	}
+	return buf.Bytes(), nil
+}
+
+// Footer prints the summary lines that 'go test' prints at the end of each
+// package.
+func (f *VTextFormatter) Footer(pkg Event) ([]byte, error) {
+	if pkg.Failed() {
+		return []byte(fmt.Sprintf("FAIL\nFAIL\t%s\t%.3fs\n", pkg.Package, pkg.Elapsed)), nil
+	}
+	return []byte(fmt.Sprintf("PASS\nok  \t%s\t%.3fs\n", pkg.Package, pkg.Elapsed)), nil
+}
+
+// JUnitFormatter prints results as a JUnit XML report, as understood by many
+// CI systems, with a testsuite element for each package and a testcase
+// element for each test, named by its sentence. Since the report must give
+// the totals for each suite, nothing is printed until Finish is called.
+type JUnitFormatter struct {
+	report junitReport
+	suite  *junitSuite
+}
+
+type junitReport struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// Header starts a new test suite for the package.
+func (f *JUnitFormatter) Header(pkg Event) ([]byte, error) {
+	f.suite = &junitSuite{
+		Name: pkg.Package,
+		Time: fmt.Sprintf("%.2f", pkg.Elapsed),
+	}
+	return nil, nil
+}
+
+// Format adds a test case for the test result to the current suite, starting
+// a suite for the test's package if Header hasn't been called.
+func (f *JUnitFormatter) Format(test Event) ([]byte, error) {
+	if f.suite == nil {
+		f.suite = &junitSuite{Name: test.Package}
+	}
+	c := junitCase{
+		Name:      test.Sentence,
+		Classname: f.suite.Name,
+		Time:      fmt.Sprintf("%.2f", test.Elapsed),
+	}
+	if test.Failed() {
+		c.Failure = &junitFailure{Message: "Failed", Output: test.Output}
+		f.suite.Failures++
+	}
+	f.suite.Tests++
+	f.suite.Cases = append(f.suite.Cases, c)
+	return nil, nil
+}
+
+// Footer adds the current suite to the report.
+func (f *JUnitFormatter) Footer(pkg Event) ([]byte, error) {
+	if f.suite == nil {
+		f.Header(pkg)
+	}
+	if f.suite.Time == "" {
+		f.suite.Time = fmt.Sprintf("%.2f", pkg.Elapsed)
+	}
+	f.report.Tests += f.suite.Tests
+	f.report.Failures += f.suite.Failures
+	f.report.Suites = append(f.report.Suites, *f.suite)
+	f.suite = nil
+	return nil, nil
+}
+
+// Finish prints the complete XML report.
+func (f *JUnitFormatter) Finish() ([]byte, error) {
+	data, err := xml.MarshalIndent(f.report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(xml.Header + string(data) + "\n"), nil
+}
@@ -0,0 +1,553 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFormatter_ReturnsFormatterForEachKnownFormat(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{
+		gotestdox.FormatText,
+		gotestdox.FormatJSON,
+		gotestdox.FormatTAP,
+		gotestdox.FormatMarkdown,
+		gotestdox.FormatJUnit,
+		gotestdox.FormatOTLP,
+		gotestdox.FormatVText,
+		gotestdox.FormatHTML,
+	} {
+		f, err := gotestdox.NewFormatter(name)
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+		if f == nil {
+			t.Errorf("%q: nil formatter", name)
+		}
+	}
+}
+
+func TestNewFormatter_ErrorsOnUnknownFormat(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.NewFormatter("bogus")
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestTextFormatter_NumbersResultsAcrossPackagesWhenGlobal(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{Numbering: gotestdox.NumberGlobal}
+	want := "p:\n   1 ✔ A (0.00s)\n\nq:\n   2 ✔ B (0.00s)\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "pass", Sentence: "A"}},
+		"q": {{Action: "pass", Sentence: "B"}},
+	}, "p", "q")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_ShowsOutputOnlyForFailingTests(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{}
+	want := "p:\n ✔ A (0.00s)\n x B (0.00s)\n    oh no\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A", Output: "    all good\n"},
+			{Action: "fail", Sentence: "B", Output: "    oh no\n"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_GroupsTestsUnderLongestSharedSubject(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{GroupSubjects: true}
+	want := "p:\n ✔ Lexer works (0.00s)\n Parser handles:\n   ✔ empty input (0.00s)\n   ✔ long input (0.00s)\n ✔ Parser (0.00s)\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "Lexer works"},
+			{Action: "pass", Sentence: "Parser handles empty input"},
+			{Action: "pass", Sentence: "Parser handles long input"},
+			{Action: "pass", Sentence: "Parser"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_CollapsesSubtestsIntoCountOnParentLine(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{CollapseSubtests: true}
+	want := "p:\n x Parser (1/2 subtests, 0.02s)\n   x Parser long input (0.01s)\n    oh no\n ✔ Parser errors (0.00s)\n ✔ Scanner (2/2 subtests, 0.00s)\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "fail", Test: "TestParser", Sentence: "Parser", Elapsed: 0.02},
+			{Action: "pass", Test: "TestParser/empty_input", Sentence: "Parser empty input"},
+			{Action: "pass", Test: "TestParserErrors", Sentence: "Parser errors"},
+			{Action: "fail", Test: "TestParser/long_input", Sentence: "Parser long input", Elapsed: 0.01, Output: "    oh no\n"},
+			{Action: "pass", Test: "TestScanner", Sentence: "Scanner"},
+			{Action: "pass", Test: "TestScanner/a", Sentence: "Scanner a"},
+			{Action: "pass", Test: "TestScanner/b", Sentence: "Scanner b"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_PacksShortPassingResultsIntoColumnsWhenWidthSet(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{Width: 30}
+	want := "p:\n ✔ A works  ✔ B works\n ✔ C works\n x D fails (0.00s)\n    oh no\n ✔ E\n ✔ This sentence is too long to pack (0.00s)\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A works"},
+			{Action: "pass", Sentence: "B works"},
+			{Action: "pass", Sentence: "C works"},
+			{Action: "fail", Sentence: "D fails", Output: "    oh no\n"},
+			{Action: "pass", Sentence: "E"},
+			{Action: "pass", Sentence: "This sentence is too long to pack"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_ShowsElapsedTimeToGivenPrecision(t *testing.T) {
+	color.NoColor = true
+	tcs := []struct {
+		precision int
+		want      string
+	}{
+		{precision: 0, want: " ✔ A (1.23s)\n"},
+		{precision: 4, want: " ✔ A (1.2346s)\n"},
+		{precision: gotestdox.WholeSeconds, want: " ✔ A (1s)\n"},
+	}
+	for _, tc := range tcs {
+		f := &gotestdox.TextFormatter{ElapsedPrecision: tc.precision}
+		got, err := f.Format(gotestdox.Event{Action: "pass", Sentence: "A", Elapsed: 1.23456})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.want != string(got) {
+			t.Errorf("precision %d: %s", tc.precision, cmp.Diff(tc.want, string(got)))
+		}
+	}
+}
+
+func TestTextFormatter_ShowsElapsedTimeInGivenUnit(t *testing.T) {
+	color.NoColor = true
+	tcs := []struct {
+		unit      string
+		precision int
+		want      string
+	}{
+		{unit: "", want: " ✔ A (1.23s)\n"},
+		{unit: gotestdox.UnitSeconds, want: " ✔ A (1.23s)\n"},
+		{unit: gotestdox.UnitSeconds, precision: gotestdox.WholeSeconds, want: " ✔ A (1s)\n"},
+		{unit: gotestdox.UnitMilliseconds, want: " ✔ A (1235ms)\n"},
+		{unit: gotestdox.UnitMilliseconds, precision: 1, want: " ✔ A (1234.6ms)\n"},
+	}
+	for _, tc := range tcs {
+		f := &gotestdox.TextFormatter{ElapsedUnit: tc.unit, ElapsedPrecision: tc.precision}
+		got, err := f.Format(gotestdox.Event{Action: "pass", Sentence: "A", Elapsed: 1.23456})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.want != string(got) {
+			t.Errorf("unit %q, precision %d: %s", tc.unit, tc.precision, cmp.Diff(tc.want, string(got)))
+		}
+	}
+}
+
+func TestTextFormatter_ShowsElapsedTimeInGivenFormat(t *testing.T) {
+	color.NoColor = true
+	tcs := map[string]string{
+		"":          " ✔ A (0.50s)\n",
+		"[%s]":      " ✔ A [0.50s]\n",
+		"took %s":   " ✔ A took 0.50s\n",
+		"%s":        " ✔ A 0.50s\n",
+		"(%s) 100%": " ✔ A (0.50s) 100%\n",
+	}
+	for format, want := range tcs {
+		f := &gotestdox.TextFormatter{ElapsedFormat: format}
+		got, err := f.Format(gotestdox.Event{Action: "pass", Sentence: "A", Elapsed: 0.5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != string(got) {
+			t.Errorf("format %q: %s", format, cmp.Diff(want, string(got)))
+		}
+	}
+}
+
+func TestTextFormatter_ShowsBarsScaledToSlowestTestInPackage(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{Bars: true}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "Slow", Elapsed: 2},
+			{Action: "pass", Sentence: "Medium", Elapsed: 0.55},
+			{Action: "pass", Sentence: "Instant", Elapsed: 0},
+		},
+		"q": {{Action: "pass", Sentence: "Quick", Elapsed: 0.01}},
+	}, "p", "q")
+	want := `p:
+ ✔ Slow (2.00s) ██████████
+ ✔ Medium (0.55s) ██▊
+ ✔ Instant (0.00s)
+
+q:
+ ✔ Quick (0.01s) ██████████
+
+`
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_UsesElapsedUnitInPackageHeader(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{PackageElapsed: true, ElapsedUnit: gotestdox.UnitMilliseconds}
+	got, err := f.Header(gotestdox.Event{Action: "pass", Package: "p", Elapsed: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "p (250ms):\n"
+	if want != string(got) {
+		t.Error(cmp.Diff(want, string(got)))
+	}
+}
+
+func TestNoTests_DistinguishesNoTestFilesFromNoTestsToRun(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]string{
+		"?   \texample.com/a\t[no test files]\n":                                                   gotestdox.NoTestFiles,
+		"testing: warning: no tests to run\nPASS\nok  \texample.com/b\t0.003s [no tests to run]\n": gotestdox.NoTestsToRun,
+		"PASS\nok  \texample.com/c\t0.003s\n":                                                      "",
+		"":                                                                                         "",
+	}
+	for output, want := range tcs {
+		got := gotestdox.NoTests(output)
+		if want != got {
+			t.Errorf("%q: want %q, got %q", output, want, got)
+		}
+	}
+}
+
+func TestTextFormatter_SaysWhenPackageHadNoTestsToRun(t *testing.T) {
+	color.NoColor = true
+	for _, f := range []*gotestdox.TextFormatter{{}, {NoPackageHeaders: true}, {Fold: true}} {
+		pkg := gotestdox.Event{
+			Action:  "pass",
+			Package: "p",
+			Output:  "testing: warning: no tests to run\nPASS\nok  \tp\t0.003s [no tests to run]\n",
+		}
+		header, err := f.Header(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		footer, err := f.Footer(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "p: no tests to run\n\n"
+		got := string(header) + string(footer)
+		if want != got {
+			t.Errorf("%+v: %s", f, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestTextFormatter_FoldsPassingPackagesToOneLine(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{Fold: true}
+	want := "p ✔ (2 tests, 0.00s)\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A"},
+			{Action: "pass", Sentence: "B"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_ShowsPassingTestsAsDotsAndFailuresInFull(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{Dots: true, Numbering: gotestdox.NumberPackage}
+	want := "p:\n ✔✔\n   3 x C (0.00s)\n    oh no\n ✔\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A"},
+			{Action: "pass", Sentence: "B"},
+			{Action: "fail", Sentence: "C", Output: "    oh no\n"},
+			{Action: "pass", Sentence: "D"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatter_SaysWhenAllTestsInPackageWereSkipped(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{NoPackageHeaders: true}
+	want := "p: all tests skipped\n"
+	got, err := f.Header(gotestdox.Event{Action: "skip", Package: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want != string(got) {
+		t.Error(cmp.Diff(want, string(got)))
+	}
+}
+
+func TestTextFormatter_DefaultTemplateMatchesDefaultFormat(t *testing.T) {
+	color.NoColor = true
+	tmpl, err := gotestdox.ParseTemplate(gotestdox.DefaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A", Elapsed: 0.123},
+			{Action: "fail", Sentence: "B", Category: "panic", Output: "    oh no\n"},
+			{Action: "pass", Sentence: "C", Retry: 2},
+		},
+	}
+	want := format(t, &gotestdox.TextFormatter{}, results, "p")
+	got := format(t, &gotestdox.TextFormatter{Template: tmpl}, results, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestParseTemplate_ErrorsOnInvalidTemplate(t *testing.T) {
+	t.Parallel()
+	for _, text := range []string{"{{.Sentence", "{{.Bogus}}"} {
+		_, err := gotestdox.ParseTemplate(text)
+		if err == nil {
+			t.Errorf("%q: want error", text)
+		}
+	}
+}
+
+func TestTextFormatter_HighlightsWholeWordsIgnoringCase(t *testing.T) {
+	color.NoColor = false
+	defer func() { color.NoColor = true }()
+	f := &gotestdox.TextFormatter{Highlight: "timeout", NoPackageHeaders: true}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "pass", Sentence: "Timeout is reset after timeouts"}},
+	}, "p")
+	style := color.New(color.Bold, color.Underline)
+	if !strings.Contains(got, style.Sprint("Timeout")+" is reset") {
+		t.Errorf("want highlighted %q in %q", "Timeout", got)
+	}
+	if strings.Contains(got, style.Sprint("timeout")) {
+		t.Errorf("want %q not highlighted in %q", "timeouts", got)
+	}
+}
+
+func TestTextFormatter_DoesNotHighlightWhenColourIsDisabled(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{Highlight: "timeout", NoPackageHeaders: true}
+	want := " ✔ Timeout is reset (0.00s)\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "pass", Sentence: "Timeout is reset"}},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestCoverage_ExtractsPercentageFromPackageOutput(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]string{
+		"coverage: 85.0% of statements\n":                      "85.0%",
+		"ok  \tdemo\t0.1s\tcoverage: 100.0% of statements\n":   "100.0%",
+		"PASS\ncoverage: 3% of statements\nok  \tdemo\t0.1s\n": "3%",
+		"ok  \tdemo\t0.1s\n":                                   "",
+		"coverage: [no statements]\n":                          "",
+	}
+	for output, want := range tcs {
+		got := gotestdox.Coverage(output)
+		if want != got {
+			t.Errorf("%q: want %q, got %q", output, want, got)
+		}
+	}
+}
+
+func TestOnelineFormatter_CountsFailuresAcrossPackages(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.OnelineFormatter{}
+	want := "x 2 failing\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "fail", Sentence: "A"}, {Action: "pass", Sentence: "B"}},
+		"q": {{Action: "fail", Sentence: "C"}},
+	}, "p", "q")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestJSONFormatter_PrintsOneLineOfJSONPerResult(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.JSONFormatter{}
+	want := `{"Action":"pass","Package":"p","Test":"TestA","Sentence":"A","Output":"","Elapsed":0.1,"Category":"","Time":"0001-01-01T00:00:00Z","Retry":0,"New":false}` + "\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "pass", Package: "p", Test: "TestA", Sentence: "A", Elapsed: 0.1}},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestVTextFormatter_ReconstructsGoTestVerboseOutput(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.VTextFormatter{}
+	want := "--- PASS: TestA (0.10s)\n    --- PASS: TestA/sub (0.00s)\n--- FAIL: TestB (0.00s)\n    oh no\nPASS\nok  \tp\t0.000s\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Test: "TestA", Elapsed: 0.1},
+			{Action: "pass", Test: "TestA/sub"},
+			{Action: "fail", Test: "TestB", Output: "    oh no\n"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTAPFormatter_NumbersTestsAndPrintsPlanAtEnd(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.TAPFormatter{}
+	want := "TAP version 13\n# p\nok 1 - A\nnot ok 2 - B\n#     oh no\n# q\nok 3 - C\n1..3\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A"},
+			{Action: "fail", Sentence: "B", Output: "    oh no\n"},
+		},
+		"q": {{Action: "pass", Sentence: "C"}},
+	}, "p", "q")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTAPFormatter_PrintsEmptyPlanWhenThereAreNoResults(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.TAPFormatter{}
+	want := "TAP version 13\n1..0\n"
+	got := format(t, f, nil)
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestMarkdownFormatter_PrintsHeadingAndListItems(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.MarkdownFormatter{}
+	want := "## p\n\n- ✔ A (0.00s)\n- x B (0.00s)\n\n  ```\n      oh no\n  ```\n\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A"},
+			{Action: "fail", Sentence: "B", Output: "    oh no\n"},
+		},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestJUnitFormatter_PrintsReportWithSuitePerPackage(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.JUnitFormatter{}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1">
+  <testsuite name="p" tests="2" failures="1" time="0.00">
+    <testcase name="A" classname="p" time="0.00"></testcase>
+    <testcase name="B &lt;b&gt;" classname="p" time="0.01">
+      <failure message="Failed">    oh no&#xA;</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="q" tests="1" failures="0" time="0.00">
+    <testcase name="C" classname="q" time="0.00"></testcase>
+  </testsuite>
+</testsuites>
+`
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "A"},
+			{Action: "fail", Sentence: "B <b>", Output: "    oh no\n", Elapsed: 0.01},
+		},
+		"q": {{Action: "pass", Sentence: "C"}},
+	}, "p", "q")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestJUnitFormatter_StartsSuiteIfFormatIsCalledWithoutHeader(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.JUnitFormatter{}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="1" failures="0">
+  <testsuite name="p" tests="1" failures="0" time="0.02">
+    <testcase name="A" classname="p" time="0.01"></testcase>
+  </testsuite>
+</testsuites>
+`
+	_, err := f.Format(gotestdox.Event{Action: "pass", Package: "p", Sentence: "A", Elapsed: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Footer(gotestdox.Event{Action: "pass", Package: "p", Elapsed: 0.02})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want != string(got) {
+		t.Error(cmp.Diff(want, string(got)))
+	}
+}
+
+// format runs the given results through f, in the same order of calls that
+// TestDoxer.Filter uses, and returns the combined output.
+func format(t *testing.T, f gotestdox.Formatter, results map[string][]gotestdox.Event, pkgs ...string) string {
+	t.Helper()
+	var out []byte
+	add := func(data []byte, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, data...)
+	}
+	for _, pkg := range pkgs {
+		event := gotestdox.Event{Action: "pass", Package: pkg}
+		add(f.Header(event))
+		for _, r := range results[pkg] {
+			add(f.Format(r))
+		}
+		add(f.Footer(event))
+	}
+	if fin, ok := f.(gotestdox.Finisher); ok {
+		add(fin.Finish())
+	}
+	return string(out)
+}
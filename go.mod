@@ -7,6 +7,7 @@ require (
 	github.com/google/go-cmp v0.5.9
 	github.com/mattn/go-isatty v0.0.19
 	github.com/rogpeppe/go-internal v1.11.0
+	golang.org/x/term v0.10.0
 	golang.org/x/text v0.11.0
 )
 
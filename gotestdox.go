@@ -2,63 +2,666 @@ package gotestdox
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
+	"regexp"
+	"runtime/debug"
+	"slices"
 	"sort"
 	"strings"
+	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
+	"golang.org/x/term"
 )
 
 const Usage = `gotestdox is a command-line tool for turning Go test names into readable sentences.
 
 Usage:
 
-	gotestdox [ARGS]
+	gotestdox [FLAGS] [ARGS]
+	gotestdox run [FLAGS] [ARGS]
+	gotestdox format [FLAGS] [FILE...]
+	gotestdox version
 
 This will run 'go test -json [ARGS]' in the current directory and format the results in a readable
 way. You can use any arguments that 'go test -json' accepts, including a list of packages, for
-example.
+example. Any of the FLAGS listed below are interpreted by gotestdox itself, rather than being
+passed on to 'go test'.
 
 If the standard input is not an interactive terminal, gotestdox will assume you want to pipe JSON
 data into it. For example:
 
 	go test -json |gotestdox
 
+To choose explicitly, use one of the subcommands: 'run' always runs 'go test', while 'format' always
+reads JSON data, either from the named files or from the standard input. 'version' prints version
+information.
+
 See https://github.com/bitfield/gotestdox for more information.`
 
 // Main runs the command-line interface for gotestdox. The exit status for the
-// binary is 0 if the tests passed, or 1 if the tests failed, or there was some
-// error.
+// binary is 0 if the tests passed, or 1 if the tests failed (or the command
+// line was invalid), or 2 if gotestdox itself failed, for example because it
+// couldn't run 'go test', or read its output.
 func Main() int {
+	// report a closed standard output as an error, so that gotestdox can
+	// stop quietly, instead of being killed by SIGPIPE
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+	td := NewTestDoxer()
+	fs := td.FlagSet()
+	version := fs.Bool("version", false, "print version information and exit")
+	fromList := fs.Bool("from-list", false, "read test names, one per line, as printed by 'go test -list', and print their sentences")
+	replay := fs.Bool("replay", false, "read saved 'go test -json' output, and print the results at the pace they originally happened")
+	speed := fs.Float64("replay-speed", 1, "with -replay, play back at this many times the original `speed`")
+	lint := fs.Bool("lint", false, "list the tests without running them, and report any whose names don't make good sentences")
+	audit := fs.Bool("audit", false, "list the tests without running them, and report any exported functions that no test mentions")
+	stdinTimeout := fs.Duration("stdin-timeout", 0, "when reading 'go test -json' output, give up if none arrives within this `duration`, such as '30s'")
+	badge := fs.Bool("badge", false, "instead of the results, print a summary in the JSON format of a shields.io endpoint badge")
+	tui := fs.Bool("tui", false, "browse the results interactively once the tests have finished, if standard output is a terminal")
 	if len(os.Args) > 1 && os.Args[1] == "-h" {
 		fmt.Println(Usage)
+		fmt.Println("\nFlags:")
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
 		return 0
 	}
-	td := NewTestDoxer()
-	if isatty.IsTerminal(os.Stdin.Fd()) {
-		td.ExecGoTest(os.Args[1:])
-	} else {
-		td.Filter()
+	if err := td.parseDefaults(fs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	userArgs, err := ParseArgs(fs, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	closeFiles, err := td.OpenFiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() {
+		if err := closeFiles(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	cmd := ""
+	if len(userArgs) > 0 && slices.Contains(subcommands, userArgs[0]) {
+		cmd, userArgs = userArgs[0], userArgs[1:]
+	}
+	var browser *Browser
+	if *tui && isatty.IsTerminal(os.Stdout.Fd()) {
+		browser = &Browser{Theme: td.Theme}
+		td.Formatter = browser
+	}
+	if *badge {
+		td.Stdout = io.Discard
+	}
+	var result RunResult
+	switch {
+	case *version, cmd == "version":
+		fmt.Println("gotestdox", Version())
+		return 0
+	case *fromList:
+		td.List()
+		result.OK = td.OK
+	case *lint:
+		td.Lint(userArgs)
+		result.OK = td.OK
+	case *audit:
+		td.Audit(userArgs)
+		result.OK = td.OK
+	case *replay:
+		if *speed <= 0 {
+			fmt.Fprintln(os.Stderr, "replay speed must be positive")
+			return 1
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		result, err = td.Replay(ctx, *speed)
+	case cmd == "run":
+		result, err = td.ExecGoTest(userArgs)
+	case cmd == "format":
+		if len(userArgs) == 0 && *stdinTimeout > 0 {
+			td.Stdin = WithTimeout(td.Stdin, *stdinTimeout)
+		}
+		result, err = td.FilterFiles(userArgs)
+	case Interactive(os.Stdin):
+		result, err = td.ExecGoTest(userArgs)
+	default:
+		if *stdinTimeout > 0 {
+			td.Stdin = WithTimeout(td.Stdin, *stdinTimeout)
+		}
+		result, err = td.Filter()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *badge {
+		data, err := json.Marshal(NewBadge(result))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(string(data))
+	}
+	if browser != nil {
+		if err := browse(browser); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
 	}
-	if !td.OK {
+	if !result.OK {
 		return 1
 	}
 	return 0
 }
 
+// WithTimeout returns a reader that reads from r, but fails with an error if
+// nothing at all has arrived from r after the given timeout. Once any data has
+// arrived, there is no time limit on the rest. This stops a misconfigured
+// pipeline, where nothing is ever written to gotestdox's standard input, from
+// waiting forever.
+func WithTimeout(r io.Reader, timeout time.Duration) io.Reader {
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	go func() {
+		<-ctx.Done()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			pw.CloseWithError(fmt.Errorf("no input received after %v: is 'go test -json' output being piped in?", timeout))
+		}
+	}()
+	go func() {
+		defer cancel()
+		_, err := io.Copy(pw, arrivalReader{r, cancel})
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// arrivalReader reads from r, calling arrived once any data has been read.
+type arrivalReader struct {
+	r       io.Reader
+	arrived func()
+}
+
+func (a arrivalReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.arrived()
+	}
+	return n, err
+}
+
+// Interactive reports whether f is an interactive terminal, and so is
+// unlikely to be supplying 'go test -json' output. A pipe (including a named
+// pipe, or FIFO) is never considered interactive, even if some quirk of the
+// platform would make it look like a terminal.
+func Interactive(f *os.File) bool {
+	info, err := f.Stat()
+	if err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd())
+}
+
+// subcommands lists the names recognised by [Main] as subcommands, when given
+// as the first argument other than gotestdox's own flags.
+var subcommands = []string{"run", "format", "version"}
+
+// Version returns the version of gotestdox in use, as recorded in the build
+// information of the running binary, followed by the version of Go it was
+// built with: for example, "v0.2.2 (go1.22.0)". If gotestdox was built from
+// a local source tree, the version is reported as "(devel)".
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	if info.Main.Path != "github.com/bitfield/gotestdox" {
+		version = ""
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/bitfield/gotestdox" {
+				version = dep.Version
+			}
+		}
+	}
+	if version == "" {
+		version = "(devel)"
+	}
+	return fmt.Sprintf("%s (%s)", version, info.GoVersion)
+}
+
 // TestDoxer holds the state and config associated with a particular invocation
 // of 'go test'.
 type TestDoxer struct {
 	Stdin          io.Reader
 	Stdout, Stderr io.Writer
 	OK             bool
+
+	// Passed and Failed count the test results seen by the most recent call
+	// to [TestDoxer.Filter].
+	Passed, Failed int
+
+	// Prettifier, if set, is used to turn test names into sentences, instead
+	// of the default behaviour of [Prettify].
+	Prettifier *Prettifier
+
+	// SentenceFunc, if set, produces the sentence shown for each test
+	// result, instead of prettifying the test's name with Prettifier (or
+	// [Prettify]). This allows any house style, such as ending every
+	// sentence with a full stop. Unless JSONCompat is set, the event has
+	// its Output and Category (for a failure) already filled in. To adjust
+	// the usual sentence, call [Prettify] on the event's Test.
+	SentenceFunc func(Event) string
+
+	// Numbering, if set, prefixes each result line with a sequence number.
+	// With [NumberPackage], numbering restarts at 1 for each package; with
+	// [NumberGlobal], it continues across the whole run.
+	Numbering string
+
+	// NoPackageHeaders suppresses the line naming each package before its
+	// results.
+	NoPackageHeaders bool
+
+	// PackageElapsed adds the total elapsed time for each package to its
+	// header line.
+	PackageElapsed bool
+
+	// ShowCoverage prints the coverage summary for each package, if it was
+	// tested with coverage enabled.
+	ShowCoverage bool
+
+	// ShowNames adds each test's full name, in parentheses, to the end of
+	// its result line, so that a sentence can be traced back to its test,
+	// even when it's been shortened (see [WithMaxWords]).
+	ShowNames bool
+
+	// ShowOutput determines which tests have their output printed beneath
+	// their results: [ShowOutputFailed] (the default), [ShowOutputAll], or
+	// [ShowOutputNone].
+	ShowOutput string
+
+	// Oneline prints nothing but a single line summarising the whole run, as
+	// produced by [OnelineFormatter].
+	Oneline bool
+
+	// Fold summarises each passing package on a single line, and shows only
+	// the failing tests of a failing package.
+	Fold bool
+
+	// Dots shows each passing test as a single check mark, without its
+	// sentence, while failing tests are shown in full.
+	Dots bool
+
+	// CollapseSubtests replaces the subtests of each test with a count of how
+	// many of them passed, shown on the parent test's line. Failing subtests
+	// are still shown in full.
+	CollapseSubtests bool
+
+	// Compact packs passing results into columns, to fit the width of the
+	// terminal. It has no effect unless td.Stdout is a terminal, or Width is
+	// set.
+	Compact bool
+
+	// Width, if non-zero, is the number of columns available for the
+	// results, instead of the detected width of the terminal.
+	Width int
+
+	// Bars adds a bar to each result line showing the test's elapsed time
+	// relative to the slowest test in its package, so that slow tests stand
+	// out.
+	Bars bool
+
+	// GroupSubjects groups together the tests in each package whose sentences
+	// begin with the same subject (for example, "Parser"), printing the
+	// subject once as a subheading above them.
+	GroupSubjects bool
+
+	// ElapsedPrecision, if non-zero, is the number of decimal places to show
+	// in elapsed times, instead of the default of 2. To round them to whole
+	// seconds, use [WholeSeconds].
+	ElapsedPrecision int
+
+	// ElapsedUnit is the unit in which elapsed times are shown:
+	// [UnitSeconds] (the default, if ElapsedUnit is empty), or
+	// [UnitMilliseconds]. Times in milliseconds are shown as whole numbers,
+	// unless ElapsedPrecision is set.
+	ElapsedUnit string
+
+	// ElapsedFormat, if set, determines how the elapsed time appears in each
+	// result line, instead of [DefaultElapsedFormat]. It must contain "%s",
+	// which is replaced by the time, such as "0.12s": for example, "[%s]",
+	// or "took %s".
+	ElapsedFormat string
+
+	// Template, if set, renders each result line, instead of the default
+	// format (see [TextFormatter.Template]).
+	Template *template.Template
+
+	// Highlight, if set, is a word to be emphasised wherever it appears in a
+	// sentence, if colour output is enabled.
+	Highlight string
+
+	// Theme, if set, determines the colours used in the output, instead of
+	// [DefaultTheme].
+	Theme Theme
+
+	// Hyperlinks makes each file location in the output of a test, such as
+	// "parser_test.go:12", a link to the file, which can be opened by
+	// clicking on it in terminals that support OSC 8 hyperlinks. It has no
+	// effect unless td.Stdout is a terminal.
+	Hyperlinks bool
+
+	// ShowModules prefixes each package header with the path of the module
+	// containing the package, in square brackets, when the current
+	// directory is in a Go workspace (see 'go help work') with more than one
+	// module. This makes it clear which module each package belongs to.
+	ShowModules bool
+
+	// PackageLinks makes the name of each package in its header a link to
+	// the package's directory, as reported by 'go list', which can be opened
+	// by clicking on it in terminals that support OSC 8 hyperlinks. Like
+	// Hyperlinks, it has no effect unless td.Stdout is a terminal.
+	PackageLinks bool
+
+	// Color determines whether the output is coloured: [ColorAuto] (the
+	// default, if Color is empty) leaves the decision to the
+	// [github.com/fatih/color] package, which disables colour unless standard
+	// output is a terminal, while [ColorAlways] and [ColorNever] override it.
+	Color string
+
+	// FailUnder, if non-zero, is the minimum percentage of tests that must
+	// pass for the run to be considered OK. This replaces the default policy,
+	// under which any test failure means the run is not OK.
+	FailUnder float64
+
+	// PackageFilter, if set, is a glob pattern selecting which packages'
+	// results are printed (see [MatchPackage]). Results from other packages
+	// still count towards td.OK, but are printed only if the package failed.
+	PackageFilter string
+
+	// Exclude, if set, hides any test whose sentence matches it, or whose
+	// name matches it if ExcludeByName is set. A failing test that is
+	// excluded still makes the run not OK, unless ExcludeIgnoresFailures is
+	// set. In that case, when [TestDoxer.ExecGoTest] runs the tests, the
+	// non-zero exit status of 'go test' is also ignored, provided the only
+	// failures were excluded tests.
+	Exclude                *regexp.Regexp
+	ExcludeByName          bool
+	ExcludeIgnoresFailures bool
+
+	// FailPattern, if set, marks as failed any passing test whose output
+	// matches it, for example to treat logged warnings as failures. Such a
+	// test has the category [CategoryFailPattern], and its package is
+	// reported as failed too.
+	FailPattern *regexp.Regexp
+
+	// MinDuration, if non-zero, hides any test that took less time than this
+	// to run. A test that took exactly MinDuration is shown. The comparison
+	// uses the elapsed time as reported, not as rounded for display. Hidden
+	// tests still count towards td.OK, and a package with no tests left to
+	// show is not printed at all.
+	MinDuration time.Duration
+
+	// NoSubtests hides the results of subtests, showing only top-level
+	// tests, while OnlySubtests hides top-level tests instead. (If both are
+	// set, every test is hidden.) Either way, hidden tests still count
+	// towards td.OK, and a package with no tests left to show is not
+	// printed at all.
+	NoSubtests, OnlySubtests bool
+
+	// Baseline, if not nil, holds the events from an earlier run, such as
+	// one saved with '-save', and any test that has no result there is
+	// marked New. As with [Diff], tests are identified by their package and
+	// name, not their sentences.
+	Baseline []Event
+
+	// TopSlowest, if non-zero, is the number of tests to list, slowest
+	// first, at the end of the run (see [Slowest]), whichever packages they
+	// belong to. The list goes to Stdout after the text results, or to
+	// Stderr if Formatter is set or JSONCompat is on, so as not to disturb
+	// machine-readable output.
+	TopSlowest int
+
+	// OwnerOf, if set, returns the owner of the package pkg, such as a team
+	// named in a CODEOWNERS file (see [Codeowners]). At the end of the run,
+	// any failing tests are listed again, grouped by owner, so that each
+	// failure can be routed to the right people. Like the TopSlowest list,
+	// this goes to Stdout, or to Stderr if Formatter is set or JSONCompat
+	// is on.
+	OwnerOf func(pkg string) string
+
+	// FirstFailureJump, if set, prints to Stderr at the end of the run, if any
+	// test failed, a command to open an editor at the first failure's
+	// location (see [FailureLocation]), so that it can be copied and run.
+	// The command is made by executing EditorCommand with a [Location], or
+	// [DefaultEditorCommand] if EditorCommand is nil.
+	FirstFailureJump bool
+	EditorCommand    *template.Template
+
+	// GroupFailuresByFile, if set, lists any failing tests again at the end
+	// of the run, grouped by the file where each failure was reported (see
+	// [FailureLocation]), so that they can be fixed one file at a time. The
+	// files with the most failures come first, and each file's failures are
+	// in order of line number. Like the TopSlowest list, this goes to
+	// Stdout, or to Stderr if Formatter is set or JSONCompat is on.
+	GroupFailuresByFile bool
+
+	// FailSummaryLimit, if positive, is the most failing tests to list again
+	// at the end of the run, in the lists for OwnerOf and
+	// GroupFailuresByFile. Any others are counted in a final line such as
+	// "…and 3 more". By default, every failure is listed.
+	FailSummaryLimit int
+
+	// SortPackages, if set to [SortPackagesFailures], holds back the results
+	// until the end of the run, and then prints the packages with the most
+	// failing tests first (and packages with equal numbers of failures in
+	// alphabetical order). By default, each package is printed as soon as it
+	// finishes.
+	SortPackages string
+
+	// Formatter, if set, determines how results are printed. If it is nil, a
+	// [TextFormatter] is used, configured according to the fields above.
+	Formatter Formatter
+
+	// JSONCompat, if set, writes each line of input to td.Stdout as it is
+	// read, instead of formatting the results. Lines are passed through
+	// unchanged, in the schema of 'go test -json', except that each test
+	// result gains a "Sentence" field. Any Outputs still receive formatted
+	// results.
+	JSONCompat bool
+
+	// Notify shows a desktop notification when [TestDoxer.ExecGoTest]
+	// finishes, giving the numbers of tests passed and failed, if the system
+	// supports it.
+	Notify bool
+
+	// Retry, if greater than zero, is the number of times that
+	// [TestDoxer.ExecGoTest] reruns any failed tests, until they pass. A test
+	// that passes on a retry counts as passed, and its result is marked as
+	// flaky.
+	Retry int
+
+	// LintIgnore lists the warnings (such as "single word") that
+	// [TestDoxer.Lint] should not report.
+	LintIgnore []string
+
+	// Save, if set, receives a copy of the raw JSON output from 'go test',
+	// when it is run by [TestDoxer.ExecGoTest], so that it can be filtered
+	// again later. With Retry, this is the output of the first run, with the
+	// results of any tests that passed on a retry substituted.
+	Save io.Writer
+
+	// EchoCommand prints the full 'go test' command line to Stderr before
+	// [TestDoxer.ExecGoTest] runs it, so that logs record exactly what was
+	// run.
+	EchoCommand bool
+
+	// OnlyChanged, if set, is a Git commit (such as "HEAD" or "main"), and
+	// [TestDoxer.ExecGoTest] tests only the packages with files changed since
+	// that commit (see [ChangedPackages]). These are added to the arguments
+	// for 'go test', so the arguments should not name any packages.
+	OnlyChanged string
+
+	// GoCommand, if set, is the name or path of the Go command run by
+	// [TestDoxer.ExecGoTest], instead of 'go'.
+	GoCommand string
+
+	// FailOnGoStderr makes the run not OK if 'go test', when run by
+	// [TestDoxer.ExecGoTest], writes anything to its standard error, such as
+	// a deprecation notice or a vet warning. This output is passed through
+	// to Stderr as usual.
+	FailOnGoStderr bool
+
+	// Outputs lists any additional destinations for the results, each with
+	// its own Formatter, besides td.Stdout. This allows several reports (for
+	// example, text and JUnit XML) to be produced from a single test run.
+	Outputs []Output
+
+	// Decoder, if set, is used to parse each line of input into an [Event],
+	// instead of [ParseJSON]. This allows results to be read from test runners
+	// whose output differs from that of 'go test -json' (see [FieldMapping]).
+	Decoder Decoder
+
+	// WarnParseErrors reports each line of input that can't be parsed to
+	// Stderr, with the reason, instead of just the number of such lines.
+	WarnParseErrors bool
+
+	// stdoutClosed records that the reader of Stdout has gone away.
+	stdoutClosed bool
+
+	// ignoredFailures counts the failing tests that didn't count, because
+	// they were excluded and ExcludeIgnoresFailures is set.
+	ignoredFailures int
+
+	// savePath and pendingOutputs record the files requested by flags such
+	// as -save and -output, to be created by OpenFiles.
+	savePath       string
+	pendingOutputs []pendingOutput
+
+	// defaulted records the flags set by the default flags, which a
+	// repeatable flag on the command line replaces (see parseDefaults).
+	defaulted map[string]bool
+
+	// err records the first error that stopped Filter from reading the
+	// input or writing the results, to be returned by Filter.
+	err error
+
+	// goStderrSeen records that 'go test' wrote to its standard error.
+	goStderrSeen bool
+}
+
+// RunResult summarises a run of the tests, as processed by
+// [TestDoxer.Filter].
+type RunResult struct {
+	// OK is true if all tests passed, or the pass rate was acceptable (see
+	// [TestDoxer.FailUnder]), just like [TestDoxer.OK].
+	OK bool
+
+	// Passed and Failed are the numbers of tests that passed and failed,
+	// and Skipped is the number that were skipped.
+	Passed, Failed, Skipped int
+
+	// Duration is how long it took to process the results (including, when
+	// running the tests, the time taken to run them).
+	Duration time.Duration
+
+	// Packages contains the result event for each package, in the order
+	// they finished, and Failures contains the result event for each
+	// failing test.
+	Packages []Event
+	Failures []Event
+
+	// ParseErrors is the number of lines of input that couldn't be parsed,
+	// and were ignored.
+	ParseErrors int
+
+	// StdoutClosed is true if the results stopped being printed part way
+	// through, because the reader of [TestDoxer.Stdout] went away (for
+	// example, when piping into 'head'). The rest of the input is not read.
+	StdoutClosed bool
+}
+
+// An Output is a destination for test results, written by [TestDoxer.Filter]
+// in the format produced by Formatter.
+type Output struct {
+	Formatter Formatter
+	Writer    io.Writer
+}
+
+// Values for [TestDoxer.SortPackages].
+const (
+	SortPackagesArrival  = "arrival"
+	SortPackagesFailures = "failures"
+)
+
+// Values for [TestDoxer.ElapsedUnit].
+const (
+	UnitSeconds      = "s"
+	UnitMilliseconds = "ms"
+)
+
+// DefaultElapsedFormat is the default value of [TestDoxer.ElapsedFormat],
+// which shows the elapsed time in parentheses.
+const DefaultElapsedFormat = "(%s)"
+
+// elapsedStyle determines how elapsed times are shown, according to the
+// ElapsedPrecision, ElapsedUnit, and ElapsedFormat fields of [TextFormatter].
+type elapsedStyle struct {
+	precision    int
+	unit, format string
 }
 
+// value returns the elapsed time, given in seconds, with its unit, such as
+// "0.12s".
+func (s elapsedStyle) value(seconds float64) string {
+	if s.unit == UnitMilliseconds {
+		return fmt.Sprintf("%.*fms", s.precision, seconds*1000)
+	}
+	return fmt.Sprintf("%.*fs", s.precision, seconds)
+}
+
+// decorate returns the elapsed time, given in seconds, as it appears in a
+// result line, such as "(0.12s)".
+func (s elapsedStyle) decorate(seconds float64) string {
+	format := s.format
+	if format == "" {
+		format = DefaultElapsedFormat
+	}
+	return strings.Replace(format, "%s", s.value(seconds), 1)
+}
+
+// WholeSeconds is the value of [TestDoxer.ElapsedPrecision] that shows
+// elapsed times with no decimal places.
+const WholeSeconds = -1
+
+// Colour modes for [TestDoxer.Color].
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Numbering modes for [TestDoxer.Numbering].
+const (
+	NumberPackage = "package"
+	NumberGlobal  = "global"
+)
+
 // NewTestDoxer returns a [*TestDoxer] configured with the default I/O streams:
 // [os.Stdin], [os.Stdout], and [os.Stderr].
 func NewTestDoxer() *TestDoxer {
@@ -70,31 +673,180 @@ func NewTestDoxer() *TestDoxer {
 }
 
 // ExecGoTest runs the 'go test -json' command, with any extra args supplied by
-// the user, and consumes its output. Any errors are reported to td's Stderr
-// stream, including the full command line that was run. If all tests passed,
-// td.OK will be true. If there was a test failure, or 'go test' returned some
-// error, then td.OK will be false.
-func (td *TestDoxer) ExecGoTest(userArgs []string) {
+// the user, and consumes its output. If all tests passed, td.OK will be true.
+// If there was a test failure, or 'go test' exited with some other non-zero
+// status, such as for a build error, then td.OK will be false, and the full
+// command line that was run is reported to td.Stderr. The same information,
+// and more, is returned as a [RunResult].
+//
+// If the tests couldn't be run, or their results couldn't be processed (see
+// [TestDoxer.Filter]), ExecGoTest returns an error, and td.OK is false.
+//
+// The command run is td.GoCommand, if set, or otherwise the value of the
+// GOTESTDOX_GO environment variable, if set, or otherwise 'go'. This allows
+// for an alternative toolchain, such as 'gotip'.
+//
+// If td.Retry is set, the results are printed only once any failed tests
+// have been retried (see [TestDoxer.Retry]).
+//
+// If td.OnlyChanged is set, only the packages changed since that commit are
+// tested. If there are none, nothing is run, and td.OK is true.
+//
+// If td.Notify is set, a desktop notification summarising the results is
+// shown once the tests have finished.
+func (td *TestDoxer) ExecGoTest(userArgs []string) (RunResult, error) {
+	if td.OnlyChanged != "" {
+		pkgs, err := ChangedPackages(".", td.OnlyChanged)
+		if err != nil {
+			td.OK = false
+			return RunResult{}, err
+		}
+		if len(pkgs) == 0 {
+			td.OK = true
+			fmt.Fprintln(td.Stderr, "no packages changed since", td.OnlyChanged)
+			return RunResult{OK: true}, nil
+		}
+		userArgs = append(pkgs, userArgs...)
+	}
+	td.goStderrSeen = false
+	if td.Retry > 0 {
+		result, err := td.execWithRetries(userArgs)
+		return td.checkGoStderr(result), err
+	}
 	args := []string{"test", "-json"}
 	args = append(args, userArgs...)
-	cmd := exec.Command("go", args...)
+	cmd := exec.Command(td.goCommand(), args...)
 	goTestOutput, err := cmd.StdoutPipe()
 	if err != nil {
-		fmt.Fprintln(td.Stderr, cmd.Args, err)
-		return
+		td.OK = false
+		return RunResult{}, fmt.Errorf("%v %w", cmd.Args, err)
 	}
-	cmd.Stderr = td.Stderr
+	cmd.Stderr = td.goStderr()
+	td.echo(cmd)
 	if err := cmd.Start(); err != nil {
-		fmt.Fprintln(td.Stderr, cmd.Args, err)
-		return
+		td.OK = false
+		return RunResult{}, fmt.Errorf("%v %w", cmd.Args, err)
+	}
+	td.Stdin = td.saving(goTestOutput)
+	result, err := td.Filter()
+	if err != nil || result.StdoutClosed {
+		// nobody's reading the results, or we can't process them, so
+		// there's no point finishing
+		cmd.Process.Kill()
+		cmd.Wait()
+		return result, err
+	}
+	if td.Notify {
+		defer td.notify()
 	}
-	td.Stdin = goTestOutput
-	td.Filter()
 	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			td.OK = false
+			result.OK = false
+			return result, fmt.Errorf("%v %w", cmd.Args, err)
+		}
+		if td.FailUnder > 0 && td.Failed > 0 {
+			// test failures have already been judged against the pass rate
+			return td.checkGoStderr(result), nil
+		}
+		if td.onlyIgnoredFailures() {
+			return td.checkGoStderr(result), nil
+		}
 		td.OK = false
+		result.OK = false
 		fmt.Fprintln(td.Stderr, cmd.Args, err)
+	}
+	return td.checkGoStderr(result), nil
+}
+
+// onlyIgnoredFailures reports whether the last run's failures, which made 'go
+// test' exit with a non-zero status, were all of excluded tests that don't
+// count (see [TestDoxer.ExcludeIgnoresFailures]), and nothing else went
+// wrong, such as a build error.
+func (td *TestDoxer) onlyIgnoredFailures() bool {
+	return td.ExcludeIgnoresFailures && td.ignoredFailures > 0 && td.OK
+}
+
+// goStderr returns the writer to be used for the standard error of 'go test'.
+// This is td.Stderr, but if td.FailOnGoStderr is set, anything written to it
+// is also noted, to be reported by checkGoStderr.
+func (td *TestDoxer) goStderr() io.Writer {
+	if !td.FailOnGoStderr {
+		return td.Stderr
+	}
+	return stderrWatcher{td}
+}
+
+// checkGoStderr makes result not OK, and says why, if td.FailOnGoStderr is
+// set and 'go test' wrote anything to its standard error.
+func (td *TestDoxer) checkGoStderr(result RunResult) RunResult {
+	if td.FailOnGoStderr && td.goStderrSeen {
+		td.OK = false
+		result.OK = false
+		fmt.Fprintln(td.Stderr, "'go test' wrote to standard error, which -fail-on-go-stderr treats as a failure")
+	}
+	return result
+}
+
+// stderrWatcher passes what 'go test' writes to its standard error through
+// to td.Stderr, noting that it did so.
+type stderrWatcher struct {
+	td *TestDoxer
+}
+
+func (w stderrWatcher) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.td.goStderrSeen = true
+	}
+	return w.td.Stderr.Write(p)
+}
+
+// saving returns a reader that reads from r, copying everything it reads to
+// td.Save, if that is set.
+func (td *TestDoxer) saving(r io.Reader) io.Reader {
+	if td.Save == nil {
+		return r
+	}
+	return io.TeeReader(r, td.Save)
+}
+
+// echo prints the command line of cmd to td.Stderr, if td.EchoCommand is
+// set, quoting any arguments that the shell would otherwise misinterpret, so
+// that it can be copied and run again.
+func (td *TestDoxer) echo(cmd *exec.Cmd) {
+	if !td.EchoCommand {
 		return
 	}
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = shellQuote(arg)
+	}
+	fmt.Fprintln(td.Stderr, strings.Join(args, " "))
+}
+
+// shellQuote returns arg in single quotes, if it contains anything but
+// letters, digits, and punctuation that's safe to use unquoted in a shell.
+func shellQuote(arg string) string {
+	unsafe := func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}
+	if arg != "" && !strings.ContainsFunc(arg, unsafe) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// goCommand returns the name of the Go command to be run by
+// [TestDoxer.ExecGoTest].
+func (td *TestDoxer) goCommand() string {
+	if td.GoCommand != "" {
+		return td.GoCommand
+	}
+	if name := os.Getenv("GOTESTDOX_GO"); name != "" {
+		return name
+	}
+	return "go"
 }
 
 // Filter reads from td's Stdin stream, line by line, processing JSON records
@@ -102,53 +854,664 @@ func (td *TestDoxer) ExecGoTest(userArgs []string) {
 //
 // For each Go package it sees records about, it will print the full name of
 // the package to td.Stdout, followed by a line giving the pass/fail status and
-// the prettified name of each test, sorted alphabetically.
+// the prettified name of each test, sorted alphabetically. If td.Formatter is
+// set, it is used to format the results instead (see [Formatter]).
+//
+// A package that passed without running any tests, because they were all
+// skipped, is given to the formatter with the action [ActionSkip], so that
+// it can be shown as skipped, rather than passing.
 //
-// If all tests passed, td.OK will be true at the end. If not, or if there was
-// a parsing error, it will be false. Errors will be reported to td.Stderr.
-func (td *TestDoxer) Filter() {
+// A line of input that can't be parsed (such as a line truncated by a broken
+// pipe) is skipped, and the rest of the input is processed as usual. The
+// number of such lines is reported to td.Stderr at the end, or, if
+// td.WarnParseErrors is set, each line is reported as it is found, along with
+// the reason.
+//
+// If all tests passed, td.OK will be true at the end. If not, or if some
+// package failed for another reason (such as a build error), or if there was
+// a parsing error, it will be false. If td.FailUnder is set, td.OK is instead
+// true only if at least that percentage of tests passed (and no package
+// failed for some other reason, such as a build error).
+//
+// Filter returns a [RunResult] summarising the run, including the OK status
+// and the counts of passed and failed tests, which are also recorded in td's
+// fields. If the input couldn't be read, or the results couldn't be formatted
+// or written, Filter stops, and returns the results so far, along with the
+// error, and td.OK is false. Failing tests are not errors in this sense:
+// they're reflected only in the result. If the reader of td.Stdout goes away,
+// that's not an error either (see [RunResult.StdoutClosed]).
+func (td *TestDoxer) Filter() (RunResult, error) {
+	start := time.Now()
+	result := RunResult{}
+	td.err = nil
+	td.filter(&result)
+	result.StdoutClosed = td.stdoutClosed
+	result.OK = td.OK
+	result.Passed, result.Failed = td.Passed, td.Failed
+	result.Duration = time.Since(start)
+	return result, td.err
+}
+
+// filter does the work of [TestDoxer.Filter], adding each package result and
+// test failure to result.
+func (td *TestDoxer) filter(result *RunResult) {
+	td.applyColor()
 	td.OK = true
+	td.Passed, td.Failed = 0, 0
+	td.ignoredFailures = 0
+	td.stdoutClosed = false
+	brokenPackage := false
+	outs := append([]Output{{td.formatter(), td.Stdout}}, td.Outputs...)
+	if td.JSONCompat {
+		outs = outs[1:]
+	}
+	decode := td.Decoder
+	if decode == nil {
+		decode = ParseJSON
+	}
+	failures := map[string]int{}
+	hidden := map[string]int{}
+	ran, skipped := map[string]int{}, map[string]int{}
 	results := map[string][]Event{}
-	outputs := map[string][]string{}
+	outputs := map[testKey][]string{}
+	held := []heldPackage{}
+	all := []Event{}
+	events, other := 0, 0
+	var known map[testKey]Event
+	if td.Baseline != nil {
+		known, _ = lastResults(td.Baseline)
+	}
 	scanner := bufio.NewScanner(td.Stdin)
 	for scanner.Scan() {
-		event, err := ParseJSON(scanner.Text())
+		if !strings.HasPrefix(strings.TrimSpace(scanner.Text()), "{") {
+			// not meant to be JSON, such as a preamble before the results
+			other++
+			continue
+		}
+		event, err := decode(scanner.Text())
 		if err != nil {
-			td.OK = false
-			fmt.Fprintln(td.Stderr, err)
+			result.ParseErrors++
+			if td.WarnParseErrors {
+				fmt.Fprintln(td.Stderr, err)
+			}
+			continue
+		}
+		events++
+		if td.JSONCompat && !td.passThrough(scanner.Text(), event) {
 			return
 		}
 		switch {
 		case event.IsPackageResult():
-			fmt.Fprintf(td.Stdout, "%s:\n", event.Package)
-			tests := results[event.Package]
-			sort.Slice(tests, func(i, j int) bool {
-				return tests[i].Sentence < tests[j].Sentence
-			})
-			for _, r := range tests {
-				fmt.Fprintln(td.Stdout, r.String())
-				if r.Action == ActionFail {
-					for _, line := range outputs[r.Test] {
-						fmt.Fprint(td.Stdout, line)
-					}
+			if event.Package == "" {
+				// malformed event with neither test nor package name
+				continue
+			}
+			event.Output = strings.Join(outputs[testKey{event.Package, ""}], "")
+			var tests []Event
+			event.Output, tests = attributeRaces(event.Output, results[event.Package])
+			if event.Passed() && ran[event.Package] == 0 && skipped[event.Package] > 0 {
+				// nothing actually ran, so report the package as skipped
+				event.Action = ActionSkip
+			}
+			pkgFailures := failures[event.Package]
+			if event.Passed() && pkgFailures > 0 {
+				// a test was marked failed by td.FailPattern
+				event.Action = ActionFail
+			}
+			allHidden := hidden[event.Package] > 0
+			// start afresh if the package turns up again, as when several
+			// runs' output is combined, so that nothing is shown twice
+			delete(outputs, testKey{event.Package, ""})
+			for _, m := range []map[string]int{failures, hidden, ran, skipped} {
+				delete(m, event.Package)
+			}
+			delete(results, event.Package)
+			result.Packages = append(result.Packages, event)
+			if event.Failed() && pkgFailures == 0 {
+				// for example, because the package didn't compile
+				brokenPackage = true
+				td.OK = false
+			}
+			if !event.Failed() && td.PackageFilter != "" && !MatchPackage(td.PackageFilter, event.Package) {
+				continue
+			}
+			if len(tests) == 0 && allHidden {
+				// every test was hidden by td.Exclude or td.MinDuration,
+				// or because it was or wasn't a subtest
+				continue
+			}
+			if td.SortPackages == SortPackagesFailures {
+				held = hold(held, heldPackage{event, tests, pkgFailures})
+				continue
+			}
+			sortTests(tests)
+			for _, out := range outs {
+				if !td.printPackage(out, event, tests) {
+					return
 				}
 			}
-			fmt.Fprintln(td.Stdout)
 		case event.IsOutput():
-			outputs[event.Test] = append(outputs[event.Test], event.Output)
+			key := testKey{event.Package, event.Test}
+			outputs[key] = append(outputs[key], event.Output)
+		case event.Skipped() && event.Test != "":
+			skipped[event.Package]++
+			result.Skipped++
 		case event.IsTestResult(), event.IsFuzzFail():
-			event.Sentence = Prettify(event.Test)
-			results[event.Package] = append(results[event.Package], event)
-			if event.Action == ActionFail {
+			ran[event.Package]++
+			event.Output = strings.Join(outputs[testKey{event.Package, event.Test}], "")
+			delete(outputs, testKey{event.Package, event.Test})
+			if event.Passed() && td.FailPattern != nil && td.FailPattern.MatchString(event.Output) {
+				event.Action = ActionFail
+				event.Category = CategoryFailPattern
+			}
+			if event.Failed() {
+				failures[event.Package]++
+				if event.Category == "" {
+					event.Category = FailureCategory(event.Output)
+				}
+			}
+			event.Sentence = td.sentence(event)
+			if known != nil {
+				_, seen := known[testKey{event.Package, event.Test}]
+				event.New = !seen
+			}
+			switch {
+			case td.excluded(event):
+				hidden[event.Package]++
+				if event.Failed() && td.ExcludeIgnoresFailures {
+					td.ignoredFailures++
+					continue
+				}
+			case td.tooFast(event):
+				hidden[event.Package]++
+				all = append(all, event)
+			case td.NoSubtests && event.IsSubtest(), td.OnlySubtests && !event.IsSubtest():
+				hidden[event.Package]++
+				all = append(all, event)
+			default:
+				results[event.Package] = append(results[event.Package], event)
+				all = append(all, event)
+			}
+			if event.Failed() {
+				td.Failed++
 				td.OK = false
+				result.Failures = append(result.Failures, event)
+			} else {
+				td.Passed++
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		td.OK = false
+		td.err = fmt.Errorf("reading input: %w", err)
+		return
+	}
+	sort.SliceStable(held, func(i, j int) bool {
+		if held[i].failures != held[j].failures {
+			return held[i].failures > held[j].failures
+		}
+		return held[i].event.Package < held[j].event.Package
+	})
+	for _, pkg := range held {
+		sortTests(pkg.tests)
+		for _, out := range outs {
+			if !td.printPackage(out, pkg.event, pkg.tests) {
+				return
+			}
+		}
+	}
+	for _, out := range outs {
+		if fin, ok := out.Formatter.(Finisher); ok {
+			if !td.writer(out.Writer)(fin.Finish()) {
+				return
+			}
+		}
+	}
+	if td.TopSlowest > 0 {
+		td.printSlowest(all)
+	}
+	if td.OwnerOf != nil {
+		td.printOwners(result.Failures)
+	}
+	if td.GroupFailuresByFile {
+		td.printFailuresByFile(result.Failures)
+	}
+	if td.FirstFailureJump {
+		td.printJump(result.Failures)
+	}
+	if td.FailUnder > 0 {
+		td.OK = !brokenPackage && td.checkPassRate()
+	}
+	if events == 0 && other > 0 {
+		// probably 'go test' without '-json'
+		td.OK = false
+		fmt.Fprintln(td.Stderr, "no JSON test results in input: is it from 'go test -json'?")
+	}
+	if result.ParseErrors > 0 {
+		td.OK = false
+		if !td.WarnParseErrors {
+			fmt.Fprintf(td.Stderr, "ignored %d invalid line(s) of input\n", result.ParseErrors)
+		}
+	}
+}
+
+// summaryWriter returns the writer for the lists printed at the end of the
+// run, such as the slowest tests: td.Stdout, or td.Stderr if td.Formatter is
+// set or td.JSONCompat is on, so as not to disturb machine-readable output.
+func (td *TestDoxer) summaryWriter() io.Writer {
+	if td.Formatter != nil || td.JSONCompat {
+		return td.Stderr
+	}
+	return td.Stdout
+}
+
+// A failureList prints the entries of one of the lists of failures at the
+// end of the run, such as the one for [TestDoxer.OwnerOf], stopping once limit
+// entries have been printed, if limit is positive.
+type failureList struct {
+	w       io.Writer
+	limit   int
+	total   int
+	printed int
+}
+
+// failureList returns a failureList for total failures, limited by
+// td.FailSummaryLimit, printing to td.summaryWriter.
+func (td *TestDoxer) failureList(total int) *failureList {
+	return &failureList{w: td.summaryWriter(), limit: td.FailSummaryLimit, total: total}
+}
+
+// full reports whether l has printed as many entries as it may.
+func (l *failureList) full() bool {
+	return l.limit > 0 && l.printed >= l.limit
+}
+
+// entry prints a failure, formatted according to format, unless l is full.
+func (l *failureList) entry(format string, args ...any) {
+	if l.full() {
+		return
+	}
+	fmt.Fprintf(l.w, format, args...)
+	l.printed++
+}
+
+// finish prints how many failures were left out, if any.
+func (l *failureList) finish() {
+	if l.printed < l.total {
+		fmt.Fprintf(l.w, "…and %d more\n", l.total-l.printed)
+	}
+}
+
+// printSlowest lists the td.TopSlowest slowest of tests, with their
+// packages, numbered in order of elapsed time.
+func (td *TestDoxer) printSlowest(tests []Event) {
+	w := td.summaryWriter()
+	elapsed := (&TextFormatter{ElapsedPrecision: td.ElapsedPrecision, ElapsedUnit: td.ElapsedUnit}).elapsed()
+	slowest := Slowest(tests, td.TopSlowest)
+	if len(slowest) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Slowest tests:")
+	for i, test := range slowest {
+		fmt.Fprintf(w, "%3d. %s %s: %s\n", i+1, elapsed.value(test.Elapsed), test.Package, test.Sentence)
+	}
+}
+
+// Slowest returns the n tests with the longest elapsed times, slowest first,
+// or all of them, if there are fewer than n. Tests that took the same time
+// stay in the order they were given.
+func Slowest(tests []Event, n int) []Event {
+	sorted := slices.Clone(tests)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Elapsed > sorted[j].Elapsed
+	})
+	return sorted[:min(n, len(sorted))]
+}
+
+// printPackage writes the header, test results, and footer for the package
+// result event pkg to out, reporting whether it succeeded.
+func (td *TestDoxer) printPackage(out Output, pkg Event, tests []Event) bool {
+	write := td.writer(out.Writer)
+	if !write(out.Formatter.Header(pkg)) {
+		return false
+	}
+	for _, r := range tests {
+		if !write(out.Formatter.Format(r)) {
+			return false
+		}
+	}
+	return write(out.Formatter.Footer(pkg))
+}
+
+// heldPackage is a package result whose printing has been put off until the
+// end of the run, so that packages can be printed in order of failures.
+type heldPackage struct {
+	event    Event
+	tests    []Event
+	failures int
+}
+
+// hold adds pkg to held, unless its package is already there, in which case
+// the two are merged, so that the package is printed only once, with all its
+// tests. The merged package failed if either part did, and was skipped only
+// if both were.
+func hold(held []heldPackage, pkg heldPackage) []heldPackage {
+	i := slices.IndexFunc(held, func(h heldPackage) bool {
+		return h.event.Package == pkg.event.Package
+	})
+	if i < 0 {
+		return append(held, pkg)
+	}
+	h := &held[i]
+	switch {
+	case h.event.Failed() || pkg.event.Failed():
+		h.event.Action = ActionFail
+	case h.event.Skipped() && pkg.event.Skipped():
+		h.event.Action = ActionSkip
+	default:
+		h.event.Action = ActionPass
+	}
+	h.event.Elapsed += pkg.event.Elapsed
+	h.event.Output += pkg.event.Output
+	h.tests = append(h.tests, pkg.tests...)
+	h.failures += pkg.failures
+	return held
+}
+
+// sortTests sorts tests alphabetically by sentence.
+func sortTests(tests []Event) {
+	sort.SliceStable(tests, func(i, j int) bool {
+		return tests[i].Sentence < tests[j].Sentence
+	})
+}
+
+// testKey identifies a test (or, if test is empty, a package) for the
+// purpose of collecting its output.
+type testKey struct {
+	pkg, test string
+}
+
+// excluded reports whether the test event should be hidden, because its
+// sentence (or its name, if td.ExcludeByName is set) matches td.Exclude.
+func (td *TestDoxer) excluded(event Event) bool {
+	if td.Exclude == nil {
+		return false
+	}
+	if td.ExcludeByName {
+		return td.Exclude.MatchString(event.Test)
+	}
+	return td.Exclude.MatchString(event.Sentence)
+}
+
+// applyColor enables or disables colour output globally, if td.Color says
+// so. This affects every TestDoxer, since colour is controlled by the
+// [github.com/fatih/color] package.
+func (td *TestDoxer) applyColor() {
+	switch td.Color {
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	}
+}
+
+// tooFast reports whether the test event should be hidden, because it took
+// less time than td.MinDuration. A test that took exactly td.MinDuration is
+// shown. The comparison uses the elapsed time as reported, to the nearest
+// nanosecond, not as rounded for display, so a test shown as "(0.01s)" may
+// still be hidden by a minimum of 10ms if it actually took 9.6ms.
+func (td *TestDoxer) tooFast(event Event) bool {
+	return elapsedDuration(event.Elapsed) < td.MinDuration
+}
+
+// elapsedDuration converts an elapsed time in seconds, as reported by 'go
+// test', to a [time.Duration], rounding to the nearest nanosecond. This
+// avoids floating-point error in comparisons: 1.1 seconds, for example, is
+// exactly 1100ms.
+func elapsedDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
+
+// checkPassRate reports whether the percentage of tests that passed meets the
+// td.FailUnder threshold. If not, the actual pass rate is reported to
+// td.Stderr. A run with no tests at all is considered to have a pass rate of
+// 100%.
+func (td *TestDoxer) checkPassRate() bool {
+	rate := 100.0
+	if total := td.Passed + td.Failed; total > 0 {
+		rate = float64(td.Passed) / float64(total) * 100
+	}
+	if rate < td.FailUnder {
+		fmt.Fprintf(td.Stderr, "pass rate %.1f%% is below the required %.1f%%\n", rate, td.FailUnder)
+		return false
+	}
+	return true
+}
+
+// List reads test names from td's Stdin stream, one per line, as printed by
+// 'go test -list', and prints the prettified sentence for each one to
+// td.Stdout. Blank lines, lines that aren't test names (such as the summary
+// line for each package), and the names of examples and benchmarks are
+// ignored. This is useful for documenting a test suite without running it.
+//
+// If there was an error reading the input, it is reported to td.Stderr and
+// td.OK will be false.
+func (td *TestDoxer) List() {
+	td.OK = true
+	scanner := bufio.NewScanner(td.Stdin)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if strings.ContainsAny(name, " \t") {
+			continue
+		}
+		if !strings.HasPrefix(name, "Test") && !strings.HasPrefix(name, "Fuzz") {
+			continue
+		}
+		fmt.Fprintln(td.Stdout, td.prettify(name))
+	}
+	if err := scanner.Err(); err != nil {
+		td.OK = false
+		fmt.Fprintln(td.Stderr, err)
+	}
+}
+
+// FilterFiles is like [TestDoxer.Filter], but reads the JSON records from each
+// of the named files in turn, instead of from td.Stdin. If no files are given,
+// it reads from td.Stdin as usual. It returns an error if any file can't be
+// opened, in which case nothing is read, or if [TestDoxer.Filter] does.
+func (td *TestDoxer) FilterFiles(paths []string) (RunResult, error) {
+	if len(paths) == 0 {
+		return td.Filter()
+	}
+	readers := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		if strings.HasPrefix(path, "-") {
+			return RunResult{}, fmt.Errorf("unknown flag %s", path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return RunResult{}, err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	td.Stdin = io.MultiReader(readers...)
+	return td.Filter()
+}
+
+// MatchPackage reports whether the import path pkg matches the glob pattern.
+// Each path segment of the pattern is matched as by [path.Match], except that
+// a segment consisting of '**' matches any number of segments (including
+// none). The pattern may match either the whole of pkg, or any trailing part
+// of it beginning at a segment boundary, so that 'internal/**' matches
+// 'github.com/octocat/mymodule/internal/api'. A malformed pattern matches
+// nothing.
+func MatchPackage(pattern, pkg string) bool {
+	patterns := strings.Split(pattern, "/")
+	names := strings.Split(pkg, "/")
+	for i := range names {
+		if matchSegments(patterns, names[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(patterns, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(patterns[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	ok, err := path.Match(patterns[0], names[0])
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(patterns[1:], names[1:])
+}
+
+// formatter returns td's Formatter, if set, or otherwise an
+// [OnelineFormatter] or [TextFormatter], configured according to td's
+// settings.
+func (td *TestDoxer) formatter() Formatter {
+	if td.Formatter != nil {
+		return td.Formatter
+	}
+	if td.Oneline {
+		return &OnelineFormatter{Theme: td.Theme}
+	}
+	locations, packages := td.hyperlinks()
+	return &TextFormatter{
+		Numbering:        td.Numbering,
+		NoPackageHeaders: td.NoPackageHeaders,
+		PackageElapsed:   td.PackageElapsed,
+		ShowCoverage:     td.ShowCoverage,
+		ShowNames:        td.ShowNames,
+		GroupSubjects:    td.GroupSubjects,
+		CollapseSubtests: td.CollapseSubtests,
+		Width:            td.compactWidth(),
+		ShowOutput:       td.ShowOutput,
+		ElapsedPrecision: td.ElapsedPrecision,
+		ElapsedUnit:      td.ElapsedUnit,
+		ElapsedFormat:    td.ElapsedFormat,
+		Fold:             td.Fold,
+		Dots:             td.Dots,
+		Bars:             td.Bars,
+		Template:         td.Template,
+		PackageDir:       locations,
+		PackageLink:      packages,
+		ModuleOf:         td.moduleNames(),
+		Highlight:        td.Highlight,
+		Theme:            td.Theme,
+	}
+}
+
+// compactWidth returns the width available for the compact layout, if
+// td.Compact is set, or zero otherwise. This is td.Width, if set, or else
+// the width of the terminal attached to td.Stdout, or 80 columns if that
+// can't be determined. If td.Stdout is not a terminal, and td.Width is not
+// set, compactWidth returns zero.
+func (td *TestDoxer) compactWidth() int {
+	if !td.Compact {
+		return 0
+	}
+	if td.Width > 0 {
+		return td.Width
+	}
+	f, ok := td.Stdout.(*os.File)
+	if !ok || !isatty.IsTerminal(f.Fd()) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 80
+	}
+	return width
+}
+
+// writer returns a function that writes data, as returned by some [Formatter]
+// method, to w. If err is not nil, it is recorded to be returned by
+// [TestDoxer.Filter] instead, td.OK is set to false, and the function returns
+// false.
+//
+// If w is buffered (that is, it has a Flush method, like a [*bufio.Writer]),
+// it is flushed after every write, so that results appear promptly even when
+// the input stream is slow.
+func (td *TestDoxer) writer(w io.Writer) func(data []byte, err error) bool {
+	return func(data []byte, err error) bool {
+		if err != nil {
+			td.OK = false
+			td.err = fmt.Errorf("formatting results: %w", err)
+			return false
+		}
+		if _, err := w.Write(data); err != nil {
+			return td.writeFailed(err)
+		}
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return td.writeFailed(err)
 			}
 		}
+		return true
+	}
+}
+
+// writeFailed handles an error writing results, and returns false. If the
+// reader has gone away, as when piping into 'head', there's nobody left to
+// tell, so gotestdox stops quietly, as other Unix tools do. Any other error is
+// recorded, to be returned by [TestDoxer.Filter], and makes the run not OK.
+func (td *TestDoxer) writeFailed(err error) bool {
+	if isBrokenPipe(err) {
+		td.stdoutClosed = true
+		return false
+	}
+	td.OK = false
+	td.err = fmt.Errorf("writing results: %w", err)
+	return false
+}
+
+// isBrokenPipe reports whether err is the result of writing to a pipe whose
+// reader has closed it.
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrClosedPipe)
+}
+
+// sentence returns the sentence to be shown for the test result event, using
+// td.SentenceFunc if it is set, or otherwise prettifying the test's name.
+func (td *TestDoxer) sentence(event Event) string {
+	if td.SentenceFunc != nil {
+		return td.SentenceFunc(event)
+	}
+	return td.prettify(event.Test)
+}
+
+// prettify turns the test name into a sentence, using td's Prettifier if there
+// is one.
+func (td *TestDoxer) prettify(name string) string {
+	if td.Prettifier == nil {
+		return Prettify(name)
 	}
+	return td.Prettifier.Prettify(name)
 }
 
 // ParseJSON takes a string representing a single JSON test record as emitted
 // by 'go test -json', and attempts to parse it into an [Event], returning any
-// parsing error encountered.
+// parsing error encountered. Fields that [Event] doesn't know about are
+// ignored.
+//
+// The only fields gotestdox really needs are Action, Package, and Test (which
+// is empty for a package result). Elapsed and Output are used if present. So
+// any test runner whose output includes these fields can be consumed, and one
+// that uses different names for them can be handled by a [FieldMapping].
 func ParseJSON(line string) (Event, error) {
 	event := Event{}
 	err := json.Unmarshal([]byte(line), &event)
@@ -158,9 +1521,88 @@ func ParseJSON(line string) (Event, error) {
 	return event, nil
 }
 
+// passThrough writes line, the JSON from which event was decoded, to
+// td.Stdout, adding a "Sentence" field if event is a test result. It
+// reports whether this succeeded.
+func (td *TestDoxer) passThrough(line string, event Event) bool {
+	if event.IsTestResult() || event.IsFuzzFail() {
+		var err error
+		line, err = AddSentence(line, td.sentence(event))
+		if err != nil {
+			td.OK = false
+			fmt.Fprintln(td.Stderr, err)
+			return false
+		}
+	}
+	if _, err := fmt.Fprintln(td.Stdout, line); err != nil {
+		return td.writeFailed(err)
+	}
+	return true
+}
+
+// AddSentence returns the JSON object line with an extra "Sentence" field
+// whose value is sentence, replacing any existing field of that name. All
+// other fields are preserved, including any that [Event] doesn't have, so
+// that the result can be read by any tool that understands the original
+// line. The fields are written in alphabetical order.
+func AddSentence(line, sentence string) (string, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return "", fmt.Errorf("parsing JSON: %w\ninput: %s", err, line)
+	}
+	data, err := json.Marshal(sentence)
+	if err != nil {
+		return "", err
+	}
+	fields["Sentence"] = data
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// A Decoder parses a single line of input into an [Event]. [ParseJSON] is the
+// default Decoder.
+type Decoder func(line string) (Event, error)
+
+// FieldMapping returns a [Decoder] for JSON records that use different field
+// names from 'go test -json'. fields maps each name used in the input to the
+// corresponding [Event] field name. For example, a runner that reports
+// "status", "target", and "duration" could be read with:
+//
+//	FieldMapping(map[string]string{
+//		"status":   "Action",
+//		"target":   "Package",
+//		"duration": "Elapsed",
+//	})
+//
+// Fields not mentioned in the mapping are decoded as usual by [ParseJSON].
+func FieldMapping(fields map[string]string) Decoder {
+	return func(line string) (Event, error) {
+		record := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return Event{}, fmt.Errorf("parsing JSON: %w\ninput: %s", err, line)
+		}
+		for from, to := range fields {
+			if value, ok := record[from]; ok {
+				delete(record, from)
+				record[to] = value
+			}
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return Event{}, err
+		}
+		return ParseJSON(string(data))
+	}
+}
+
 const (
-	ActionPass = "pass"
-	ActionFail = "fail"
+	ActionPass   = "pass"
+	ActionFail   = "fail"
+	ActionSkip   = "skip"
+	ActionOutput = "output"
 )
 
 // Event represents a Go test event as recorded by the 'go test -json' command.
@@ -174,25 +1616,99 @@ type Event struct {
 	Sentence string
 	Output   string
 	Elapsed  float64
+
+	// Category is the kind of failure, if the test failed in some
+	// recognisable way (see [FailureCategory]).
+	Category string
+
+	// Time is when the event was recorded by 'go test', if known.
+	Time time.Time
+
+	// Retry is the number of the retry on which the test passed, if it failed
+	// at first (see [TestDoxer.Retry]).
+	Retry int
+
+	// New is true if the test has no result in the earlier run given by
+	// [TestDoxer.Baseline].
+	New bool
+}
+
+// NewEvent returns an [Event] with the given action (such as [ActionPass]),
+// package, and test name, and the sentence that [Prettify] makes from the test
+// name, if there is one. This is convenient for constructing events in tests,
+// or for a program that gets its test results from somewhere other than 'go
+// test -json'.
+func NewEvent(action, pkg, test string) Event {
+	e := Event{
+		Action:  action,
+		Package: pkg,
+		Test:    test,
+	}
+	if test != "" {
+		e.Sentence = Prettify(test)
+	}
+	return e
+}
+
+// Clone returns a copy of e, which can be modified without affecting e. At
+// present, an Event holds no references to shared data, so this is the same
+// as assigning it to another variable, but Clone will continue to make an
+// independent copy even if fields such as slices are added in future.
+func (e Event) Clone() Event {
+	return e
 }
 
 // String formats a test Event for display. The prettified test name will be
 // prefixed by a ✔ if the test passed, or an x if it failed.
 //
 // The sentence generated by [Prettify] from the name of the test will be
-// shown, followed by the elapsed time in parentheses, to 2 decimal places.
+// shown, followed by the elapsed time in parentheses, to 2 decimal places, and
+// the Category of the failure in square brackets, if there is one. A test that
+// passed only on a retry is marked as flaky, and a New test is marked as new.
 //
 // # Colour
 //
 // If the program is attached to an interactive terminal, as determined by
 // [github.com/mattn/go-isatty], and the NO_COLOR environment variable is not
-// set, check marks will be shown in green and x's in red.
+// set, check marks will be shown in green and x's in red, as specified by
+// [DefaultTheme].
 func (e Event) String() string {
-	status := color.RedString("x")
-	if e.Action == ActionPass {
-		status = color.GreenString("✔")
+	return e.render(DefaultTheme, elapsedStyle{precision: 2})
+}
+
+// render formats e in the same way as [Event.String], but using the colours
+// from theme, and showing the elapsed time in the given style.
+func (e Event) render(theme Theme, elapsed elapsedStyle) string {
+	status := theme.paint(RoleFail, "x")
+	if e.Passed() {
+		status = theme.paint(RolePass, "✔")
+	}
+	line := fmt.Sprintf(" %s %s %s", status, e.Sentence, elapsed.decorate(e.Elapsed))
+	if e.Category != "" {
+		line += " [" + e.Category + "]"
+	}
+	if e.Retry > 0 {
+		line += fmt.Sprintf(" (flaky, passed on retry %d)", e.Retry)
 	}
-	return fmt.Sprintf(" %s %s (%.2fs)", status, e.Sentence, e.Elapsed)
+	if e.New {
+		line += " (new)"
+	}
+	return line
+}
+
+// Passed reports whether the event's action is [ActionPass].
+func (e Event) Passed() bool {
+	return e.Action == ActionPass
+}
+
+// Failed reports whether the event's action is [ActionFail].
+func (e Event) Failed() bool {
+	return e.Action == ActionFail
+}
+
+// Skipped reports whether the event's action is [ActionSkip].
+func (e Event) Skipped() bool {
+	return e.Action == ActionSkip
 }
 
 // IsTestResult determines whether or not the test event is one that we are
@@ -213,17 +1729,23 @@ func (e Event) IsTestResult() bool {
 	if e.Test == "" {
 		return false
 	}
-	if e.Action == ActionPass || e.Action == ActionFail {
+	if e.Passed() || e.Failed() {
 		return true
 	}
 	return false
 }
 
+// IsSubtest reports whether the event is about a subtest, such as
+// "TestParse/empty_input", rather than a top-level test.
+func (e Event) IsSubtest() bool {
+	return strings.Contains(e.Test, "/")
+}
+
 func (e Event) IsFuzzFail() bool {
 	if !strings.HasPrefix(e.Test, "Fuzz") {
 		return false
 	}
-	if e.Action != ActionFail {
+	if !e.Failed() {
 		return false
 	}
 	return true
@@ -236,7 +1758,7 @@ func (e Event) IsPackageResult() bool {
 	if e.Test != "" {
 		return false
 	}
-	if e.Action == ActionPass || e.Action == ActionFail {
+	if e.Passed() || e.Failed() {
 		return true
 	}
 	return false
@@ -246,13 +1768,14 @@ func (e Event) IsPackageResult() bool {
 // from [testing.T.Error]), excluding status messages automatically generated
 // by 'go test' such as "--- FAIL: ..." or "=== RUN / PAUSE / CONT".
 func (e Event) IsOutput() bool {
-	if e.Action != "output" {
+	if e.Action != ActionOutput {
 		return false
 	}
 	if strings.HasPrefix(e.Output, "---") {
 		return false
 	}
-	if strings.HasPrefix(e.Output, "===") {
+	if strings.HasPrefix(e.Output, "=== ") {
+		// but not the lines of '=' delimiting a race detector report
 		return false
 	}
 	return true
@@ -1,12 +1,21 @@
 package gotestdox_test
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bitfield/gotestdox"
 	"github.com/fatih/color"
@@ -22,11 +31,45 @@ func TestMain(m *testing.M) {
 
 func TestGotestdoxProducesCorrectOutputWhen(t *testing.T) {
 	t.Parallel()
+	// Share the build cache, so that scripts which run 'go test' are fast
+	gocache, err := exec.Command("go", "env", "GOCACHE").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
 	testscript.Run(t, testscript.Params{
 		Dir: "testdata/script",
+		Setup: func(env *testscript.Env) error {
+			env.Setenv("GOCACHE", strings.TrimSpace(string(gocache)))
+			return nil
+		},
 	})
 }
 
+func TestInteractive_IsFalseForPipe(t *testing.T) {
+	t.Parallel()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if gotestdox.Interactive(r) {
+		t.Error("want pipe not to be interactive")
+	}
+}
+
+func TestInteractive_IsFalseForRegularFile(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open("testdata/script/version_requested.txtar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if gotestdox.Interactive(f) {
+		t.Error("want regular file not to be interactive")
+	}
+}
+
 func TestParseJSON_ReturnsValidDataForValidJSON(t *testing.T) {
 	t.Parallel()
 	input := `{"Time":"2022-02-28T15:53:43.532326Z","Action":"pass","Package":"github.com/bitfield/script","Test":"TestFindFilesInNonexistentPathReturnsError","Elapsed":0.12}`
@@ -35,6 +78,7 @@ func TestParseJSON_ReturnsValidDataForValidJSON(t *testing.T) {
 		Package: "github.com/bitfield/script",
 		Test:    "TestFindFilesInNonexistentPathReturnsError",
 		Elapsed: 0.12,
+		Time:    time.Date(2022, time.February, 28, 15, 53, 43, 532326000, time.UTC),
 	}
 
 	got, err := gotestdox.ParseJSON(input)
@@ -55,6 +99,103 @@ func TestParseJSON_ErrorsOnInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestAddSentence_PreservesFieldsEventDoesNotHave(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestFoo","Source":{"File":"foo_test.go"},"Attempt":2}`
+	got, err := gotestdox.AddSentence(input, "Foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Action":"pass","Attempt":2,"Package":"p","Sentence":"Foo","Source":{"File":"foo_test.go"},"Test":"TestFoo"}`
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAddSentence_ErrorsOnInvalidJSON(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.AddSentence(`["not", "an", "object"]`, "Foo")
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestFilter_WithJSONCompatPassesInputThroughAddingSentences(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"run","Package":"p","Test":"TestFoo"}
+{"Action":"fail","Package":"p","Test":"TestFoo"}
+{"Action":"fail","Package":"p"}`
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin:      strings.NewReader(input),
+		Stdout:     buf,
+		Stderr:     io.Discard,
+		JSONCompat: true,
+	}
+	td.Filter()
+	want := `{"Action":"run","Package":"p","Test":"TestFoo"}
+{"Action":"fail","Package":"p","Sentence":"Foo","Test":"TestFoo"}
+{"Action":"fail","Package":"p"}
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.OK {
+		t.Error("want not ok")
+	}
+}
+
+func TestFieldMapping_RenamesFieldsBeforeDecoding(t *testing.T) {
+	t.Parallel()
+	decode := gotestdox.FieldMapping(map[string]string{
+		"status":   "Action",
+		"target":   "Package",
+		"name":     "Test",
+		"duration": "Elapsed",
+	})
+	input := `{"status":"pass","target":"//foo:bar","name":"TestFooWorks","duration":0.5,"extra":true}`
+	want := gotestdox.Event{
+		Action:  "pass",
+		Package: "//foo:bar",
+		Test:    "TestFooWorks",
+		Elapsed: 0.5,
+	}
+	got, err := decode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFieldMapping_ErrorsOnInvalidJSON(t *testing.T) {
+	t.Parallel()
+	decode := gotestdox.FieldMapping(map[string]string{"status": "Action"})
+	_, err := decode("invalid")
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestFilter_UsesDecoderIfSet(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"status":"pass","Package":"p","Test":"TestA"}
+{"status":"pass","Package":"p"}`),
+		Stdout:  io.Discard,
+		Stderr:  io.Discard,
+		Decoder: gotestdox.FieldMapping(map[string]string{"status": "Action"}),
+	}
+	td.Filter()
+	if !td.OK {
+		t.Error("want OK")
+	}
+	if td.Passed != 1 {
+		t.Errorf("want 1 passed, got %d", td.Passed)
+	}
+}
+
 func TestEventString_FormatsPassAndFailEventsDifferently(t *testing.T) {
 	t.Parallel()
 	pass := gotestdox.Event{
@@ -70,6 +211,41 @@ func TestEventString_FormatsPassAndFailEventsDifferently(t *testing.T) {
 	}
 }
 
+func TestNewEvent_SetsSentenceFromTestName(t *testing.T) {
+	t.Parallel()
+	want := gotestdox.Event{
+		Action:   gotestdox.ActionPass,
+		Package:  "p",
+		Test:     "TestParsesInput",
+		Sentence: "Parses input",
+	}
+	got := gotestdox.NewEvent(gotestdox.ActionPass, "p", "TestParsesInput")
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestNewEvent_LeavesSentenceEmptyForPackageEvent(t *testing.T) {
+	t.Parallel()
+	got := gotestdox.NewEvent(gotestdox.ActionFail, "p", "")
+	if got.Sentence != "" {
+		t.Errorf("want empty sentence, got %q", got.Sentence)
+	}
+	if !got.IsPackageResult() {
+		t.Error("want package result")
+	}
+}
+
+func TestEventClone_ReturnsIndependentCopy(t *testing.T) {
+	t.Parallel()
+	orig := gotestdox.NewEvent(gotestdox.ActionFail, "p", "TestA")
+	clone := orig.Clone()
+	clone.Action = gotestdox.ActionPass
+	if !orig.Failed() {
+		t.Error("modifying clone changed original")
+	}
+}
+
 func TestIsFuzzFail_IsTrueForFuzzFailEvents(t *testing.T) {
 	t.Parallel()
 	event := gotestdox.Event{
@@ -100,6 +276,57 @@ func TestIsFuzzFail_IsFalseForNonFuzzFailEvents(t *testing.T) {
 	}
 }
 
+func TestPassed_IsTrueOnlyForPassEvents(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]bool{
+		"pass":   true,
+		"fail":   false,
+		"skip":   false,
+		"run":    false,
+		"output": false,
+	}
+	for action, want := range tcs {
+		event := gotestdox.Event{Action: action, Test: "TestFooDoesX"}
+		if event.Passed() != want {
+			t.Errorf("%q event: want %t", action, want)
+		}
+	}
+}
+
+func TestFailed_IsTrueOnlyForFailEvents(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]bool{
+		"pass":   false,
+		"fail":   true,
+		"skip":   false,
+		"run":    false,
+		"output": false,
+	}
+	for action, want := range tcs {
+		event := gotestdox.Event{Action: action, Test: "TestFooDoesX"}
+		if event.Failed() != want {
+			t.Errorf("%q event: want %t", action, want)
+		}
+	}
+}
+
+func TestSkipped_IsTrueOnlyForSkipEvents(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]bool{
+		"pass":   false,
+		"fail":   false,
+		"skip":   true,
+		"run":    false,
+		"output": false,
+	}
+	for action, want := range tcs {
+		event := gotestdox.Event{Action: action, Test: "TestFooDoesX"}
+		if event.Skipped() != want {
+			t.Errorf("%q event: want %t", action, want)
+		}
+	}
+}
+
 func TestIsTestResult_IsTrueForTestPassOrFailEvents(t *testing.T) {
 	t.Parallel()
 	tcs := []gotestdox.Event{
@@ -222,6 +449,934 @@ func TestExecGoTest_SetsOKToFalseWhenCommandErrors(t *testing.T) {
 	}
 }
 
+func TestExecGoTestWithFailOnGoStderr_IsNotOKIfGoTestWritesToStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	t.Parallel()
+	fakeGo := filepath.Join(t.TempDir(), "go")
+	err := os.WriteFile(fakeGo, []byte(`#!/bin/sh
+echo 'go: warning: -i flag is deprecated' >&2
+echo '{"Action":"pass","Package":"p","Test":"TestA"}'
+echo '{"Action":"pass","Package":"p"}'
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, failOnStderr := range []bool{false, true} {
+		stderr := &bytes.Buffer{}
+		td := gotestdox.TestDoxer{
+			Stdout:         io.Discard,
+			Stderr:         stderr,
+			GoCommand:      fakeGo,
+			FailOnGoStderr: failOnStderr,
+		}
+		result, err := td.ExecGoTest(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.OK == failOnStderr {
+			t.Errorf("FailOnGoStderr %t: want OK %t, got %t", failOnStderr, !failOnStderr, result.OK)
+		}
+		if !strings.Contains(stderr.String(), "-i flag is deprecated") {
+			t.Errorf("FailOnGoStderr %t: want go test's stderr passed through, got %q", failOnStderr, stderr)
+		}
+	}
+}
+
+func TestParseArgs_ConsumesOwnFlagsAndPassesOthersThrough(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.NewTestDoxer()
+	args := []string{"-run", "TestFoo", "-number", "global", "./...", "-args", "-number"}
+	want := []string{"-run", "TestFoo", "./...", "-args", "-number"}
+	got, err := gotestdox.ParseArgs(td.FlagSet(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if td.Numbering != gotestdox.NumberGlobal {
+		t.Errorf("want numbering %q, got %q", gotestdox.NumberGlobal, td.Numbering)
+	}
+}
+
+func TestParseArgs_ErrorsOnMissingFlagValue(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.NewTestDoxer()
+	_, err := gotestdox.ParseArgs(td.FlagSet(), []string{"-number"})
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestDefaultFlags_ReadsFileThenEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, gotestdox.DefaultsFile), []byte("# team defaults\n-fold -number global\n\n-initialisms\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOTESTDOX_FLAGS", "  -number package ")
+	got, err := gotestdox.DefaultFlags(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-fold", "-number", "global", "-initialisms", "-number", "package"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDefaultFlags_IsEmptyWithNoFileOrEnvironment(t *testing.T) {
+	t.Setenv("GOTESTDOX_FLAGS", "")
+	got, err := gotestdox.DefaultFlags(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no flags, got %q", got)
+	}
+}
+
+func TestDefaultFlags_AreOverriddenByLaterFlags(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.NewTestDoxer()
+	fs := td.FlagSet()
+	if _, err := gotestdox.ParseArgs(fs, []string{"-number", "global", "-fold"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gotestdox.ParseArgs(fs, []string{"-number", "package", "./..."}); err != nil {
+		t.Fatal(err)
+	}
+	if td.Numbering != gotestdox.NumberPackage {
+		t.Errorf("want numbering %q from command line, got %q", gotestdox.NumberPackage, td.Numbering)
+	}
+	if !td.Fold {
+		t.Error("want fold from defaults")
+	}
+}
+
+func TestFilter_UsesSentenceFuncIfSet(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"output","Package":"p","Test":"TestPanics","Output":"panic: oh no\n"}
+{"Action":"fail","Package":"p","Test":"TestPanics"}
+{"Action":"pass","Package":"p","Test":"TestParsesInput"}
+{"Action":"fail","Package":"p"}`),
+		Stdout:     buf,
+		Stderr:     io.Discard,
+		ShowOutput: gotestdox.ShowOutputNone,
+		SentenceFunc: func(e gotestdox.Event) string {
+			sentence := gotestdox.Prettify(e.Test) + "."
+			if e.Category == "panic" {
+				sentence = "💥 " + sentence
+			}
+			return sentence
+		},
+	}
+	td.Filter()
+	want := "p:\n ✔ Parses input. (0.00s)\n x 💥 Panics. (0.00s) [panic]\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_UsesSentenceFuncWithJSONCompat(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin:      strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}`),
+		Stdout:     buf,
+		Stderr:     io.Discard,
+		JSONCompat: true,
+		SentenceFunc: func(e gotestdox.Event) string {
+			return "custom " + e.Test
+		},
+	}
+	td.Filter()
+	want := `{"Action":"pass","Package":"p","Sentence":"custom TestA","Test":"TestA"}` + "\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_CountsPassedAndFailedTests(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p"}`),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	td.Filter()
+	if td.Passed != 2 {
+		t.Errorf("want 2 passed, got %d", td.Passed)
+	}
+	if td.Failed != 1 {
+		t.Errorf("want 1 failed, got %d", td.Failed)
+	}
+}
+
+func TestFilter_MarksPassingTestFailedIfOutputMatchesFailPattern(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"output","Package":"p","Test":"TestA","Output":"WARN: careful\n"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"all good\n"}
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p"}`),
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		FailPattern: regexp.MustCompile(`WARN`),
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK {
+		t.Error("want not OK")
+	}
+	if result.Passed != 1 || result.Failed != 1 {
+		t.Errorf("want 1 passed and 1 failed, got %d and %d", result.Passed, result.Failed)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Category != gotestdox.CategoryFailPattern {
+		t.Errorf("want TestA failed with category %q, got %v", gotestdox.CategoryFailPattern, result.Failures)
+	}
+	if len(result.Packages) != 1 || !result.Packages[0].Failed() {
+		t.Errorf("want package p failed, got %v", result.Packages)
+	}
+}
+
+func TestFilter_StopsQuietlyWhenReaderClosesStdout(t *testing.T) {
+	t.Parallel()
+	pr, pw := io.Pipe()
+	go func() {
+		// read the first package's results, then go away, as 'head' would
+		pr.Read(make([]byte, 1024))
+		pr.Close()
+	}()
+	stderr := new(bytes.Buffer)
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}
+{"Action":"pass","Package":"q","Test":"TestB"}
+{"Action":"pass","Package":"q"}
+{"Action":"pass","Package":"r","Test":"TestC"}
+{"Action":"pass","Package":"r"}`),
+		Stdout: pw,
+		Stderr: stderr,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.StdoutClosed {
+		t.Error("want StdoutClosed")
+	}
+	if !result.OK {
+		t.Error("want OK, since no test failed")
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("want nothing printed to stderr, got %q", stderr)
+	}
+}
+
+func TestFilter_WritesResultsToEachOutputFromOneInput(t *testing.T) {
+	color.NoColor = true
+	text, tap := &bytes.Buffer{}, &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}`),
+		Stdout: text,
+		Stderr: io.Discard,
+		Outputs: []gotestdox.Output{
+			{Formatter: &gotestdox.TAPFormatter{}, Writer: tap},
+		},
+	}
+	td.Filter()
+	wantText := "p:\n ✔ A (0.00s)\n\n"
+	if wantText != text.String() {
+		t.Error(cmp.Diff(wantText, text.String()))
+	}
+	wantTAP := "TAP version 13\n# p\nok 1 - A\n1..1\n"
+	if wantTAP != tap.String() {
+		t.Error(cmp.Diff(wantTAP, tap.String()))
+	}
+}
+
+func TestFilter_ReturnsRunResultSummarisingRun(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p"}
+{"Action":"pass","Package":"q","Test":"TestC"}
+{"Action":"pass","Package":"q"}`),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK {
+		t.Error("want not OK")
+	}
+	if result.Passed != 2 || result.Failed != 1 {
+		t.Errorf("want 2 passed and 1 failed, got %d and %d", result.Passed, result.Failed)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Test != "TestB" {
+		t.Errorf("want failure TestB, got %v", result.Failures)
+	}
+	var pkgs []string
+	for _, p := range result.Packages {
+		pkgs = append(pkgs, p.Package+" "+p.Action)
+	}
+	want := []string{"p fail", "q pass"}
+	if !cmp.Equal(want, pkgs) {
+		t.Error(cmp.Diff(want, pkgs))
+	}
+}
+
+func TestFilter_IsOKWhenPassRateMeetsFailUnderThreshold(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p"}`),
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+		FailUnder: 50,
+	}
+	td.Filter()
+	if !td.OK {
+		t.Error("want ok")
+	}
+}
+
+func TestFilter_FlushesBufferedOutputBeforeInputEnds(t *testing.T) {
+	t.Parallel()
+	input, w := io.Pipe()
+	defer w.Close()
+	output := &lockedBuffer{}
+	td := gotestdox.TestDoxer{
+		Stdin:  input,
+		Stdout: bufio.NewWriter(output),
+		Stderr: io.Discard,
+	}
+	go td.Filter()
+	fmt.Fprintln(w, `{"Action":"pass","Package":"p","Test":"TestA"}`)
+	fmt.Fprintln(w, `{"Action":"pass","Package":"p"}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), "A (0.00s)") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no output for completed package before end of input: %q", output.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// lockedBuffer is a [bytes.Buffer] that's safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFilter_IgnoresResultEventsWithNoTestOrPackageName(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Test":""}
+{"Action":"fail"}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+	}
+	td.Filter()
+	if buf.Len() > 0 {
+		t.Errorf("want no output, got %q", buf.String())
+	}
+	if !td.OK {
+		t.Error("want ok")
+	}
+}
+
+func TestFilter_TreatsResultWithEmptyTestNameAsPackageResult(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":""}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+	}
+	td.Filter()
+	want := "p:\n ✔ A (0.00s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.Passed != 1 {
+		t.Errorf("want 1 passed, got %d", td.Passed)
+	}
+}
+
+func TestFilter_HidesTestsFasterThanMinDurationButStillCountsThem(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestSlow","Elapsed":0.5}
+{"Action":"fail","Package":"p","Test":"TestFast","Elapsed":0.001}
+{"Action":"fail","Package":"p","Elapsed":0.51}
+{"Action":"pass","Package":"q","Test":"TestFast","Elapsed":0}
+{"Action":"pass","Package":"q","Elapsed":0.01}`),
+		Stdout:      buf,
+		Stderr:      io.Discard,
+		MinDuration: 10 * time.Millisecond,
+	}
+	td.Filter()
+	want := "p:\n ✔ Slow (0.50s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.Passed != 2 || td.Failed != 1 {
+		t.Errorf("want 2 passed and 1 failed, got %d and %d", td.Passed, td.Failed)
+	}
+	if td.OK {
+		t.Error("want not ok")
+	}
+}
+
+func TestFilter_ShowsTestThatTookExactlyMinDuration(t *testing.T) {
+	color.NoColor = true
+	tcs := []struct {
+		min     time.Duration
+		elapsed string
+		shown   bool
+	}{
+		{min: 10 * time.Millisecond, elapsed: "0.01", shown: true},
+		{min: 10 * time.Millisecond, elapsed: "0.0096", shown: false},
+		{min: 10 * time.Millisecond, elapsed: "0.0101", shown: true},
+		// 1.14 seconds isn't exactly representable as a float, and comparing
+		// floats would make it less than 1140ms
+		{min: 1140 * time.Millisecond, elapsed: "1.14", shown: true},
+		{min: 1140 * time.Millisecond, elapsed: "1.139", shown: false},
+		{min: time.Second, elapsed: "1", shown: true},
+	}
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+		td := gotestdox.TestDoxer{
+			Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA","Elapsed":` + tc.elapsed + `}
+{"Action":"pass","Package":"p","Test":"TestSlow","Elapsed":5}
+{"Action":"pass","Package":"p"}`),
+			Stdout:      buf,
+			Stderr:      io.Discard,
+			MinDuration: tc.min,
+		}
+		td.Filter()
+		shown := strings.Contains(buf.String(), " A ")
+		if tc.shown != shown {
+			t.Errorf("min %v, elapsed %ss: want shown %t, got %t", tc.min, tc.elapsed, tc.shown, shown)
+		}
+	}
+}
+
+func TestFilter_HidesSubtestsOrTopLevelTestsWhenRequested(t *testing.T) {
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestParse/empty_input"}
+{"Action":"fail","Package":"p","Test":"TestParse/bad_input"}
+{"Action":"fail","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Test":"TestFormat"}
+{"Action":"fail","Package":"p"}
+{"Action":"pass","Package":"q","Test":"TestOnlyTopLevel"}
+{"Action":"pass","Package":"q"}`
+	tcs := []struct {
+		name         string
+		noSubtests   bool
+		onlySubtests bool
+		want         string
+	}{
+		{
+			name:       "no subtests",
+			noSubtests: true,
+			want:       "p:\n ✔ Format (0.00s)\n x Parse (0.00s)\n\nq:\n ✔ Only top level (0.00s)\n\n",
+		},
+		{
+			name:         "only subtests",
+			onlySubtests: true,
+			want:         "p:\n x Parse bad input (0.00s)\n ✔ Parse empty input (0.00s)\n\n",
+		},
+	}
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+		td := gotestdox.TestDoxer{
+			Stdin:        strings.NewReader(input),
+			Stdout:       buf,
+			Stderr:       io.Discard,
+			NoSubtests:   tc.noSubtests,
+			OnlySubtests: tc.onlySubtests,
+		}
+		result, err := td.Filter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.want != buf.String() {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, buf.String()))
+		}
+		if result.OK {
+			t.Errorf("%s: want not OK", tc.name)
+		}
+		if result.Passed != 3 || result.Failed != 2 {
+			t.Errorf("%s: want 3 passed and 2 failed, got %d and %d", tc.name, result.Passed, result.Failed)
+		}
+	}
+}
+
+func TestEvent_IsSubtestIsTrueOnlyForSubtests(t *testing.T) {
+	t.Parallel()
+	if (gotestdox.Event{Test: "TestParse"}).IsSubtest() {
+		t.Error("want TestParse not a subtest")
+	}
+	if !(gotestdox.Event{Test: "TestParse/empty_input"}).IsSubtest() {
+		t.Error("want TestParse/empty_input a subtest")
+	}
+}
+
+func TestFilter_SkipsAndCountsLinesThatCannotBeParsed(t *testing.T) {
+	t.Parallel()
+	stderr := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass" "Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p"}
+{"Action":"pass","Pack`),
+		Stdout: io.Discard,
+		Stderr: stderr,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ParseErrors != 2 {
+		t.Errorf("want 2 parse errors, got %d", result.ParseErrors)
+	}
+	if result.Passed != 1 || len(result.Packages) != 1 {
+		t.Errorf("want rest of input processed, got %d passed in %d packages", result.Passed, len(result.Packages))
+	}
+	if result.OK {
+		t.Error("want not ok")
+	}
+	want := "ignored 2 invalid line(s) of input\n"
+	if want != stderr.String() {
+		t.Error(cmp.Diff(want, stderr.String()))
+	}
+}
+
+func TestFilter_SkipsLinesThatAreNotJSONWithoutError(t *testing.T) {
+	t.Parallel()
+	stderr := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`preamble from the CI system
+{"Action":"pass","Package":"p","Test":"TestA"}
+=== RUN   TestB
+
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p"}
+done`),
+		Stdout: io.Discard,
+		Stderr: stderr,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK {
+		t.Error("want ok")
+	}
+	if result.Passed != 2 || result.ParseErrors != 0 {
+		t.Errorf("want 2 passed and no parse errors, got %d and %d", result.Passed, result.ParseErrors)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("want nothing printed to stderr, got %q", stderr)
+	}
+}
+
+func TestFilter_IsNotOKIfInputHasNoJSONAtAll(t *testing.T) {
+	t.Parallel()
+	stderr := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin:  strings.NewReader("=== RUN   TestA\n--- PASS: TestA (0.00s)\nPASS\nok  \tp\t0.003s\n"),
+		Stdout: io.Discard,
+		Stderr: stderr,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK {
+		t.Error("want not ok")
+	}
+	if !strings.Contains(stderr.String(), "no JSON test results") {
+		t.Errorf("want explanation, got %q", stderr)
+	}
+}
+
+func TestFilter_PrintsOneHeaderPerPackageWhenEventsAreInterleaved(t *testing.T) {
+	color.NoColor = true
+	for _, order := range []string{gotestdox.SortPackagesArrival, gotestdox.SortPackagesFailures} {
+		buf := &bytes.Buffer{}
+		td := gotestdox.TestDoxer{
+			Stdin: strings.NewReader(`{"Action":"run","Package":"a","Test":"TestA1"}
+{"Action":"run","Package":"b","Test":"TestB1"}
+{"Action":"output","Package":"b","Test":"TestB1","Output":"    b_test.go:5: oh no\n"}
+{"Action":"output","Package":"a","Test":"TestA1","Output":"    a_test.go:5: fine\n"}
+{"Action":"pass","Package":"a","Test":"TestA1"}
+{"Action":"fail","Package":"b","Test":"TestB1"}
+{"Action":"pass","Package":"b","Test":"TestB2"}
+{"Action":"pass","Package":"a","Test":"TestA2"}
+{"Action":"pass","Package":"a"}
+{"Action":"fail","Package":"b"}`),
+			Stdout:       buf,
+			Stderr:       io.Discard,
+			SortPackages: order,
+		}
+		td.Filter()
+		got := buf.String()
+		for _, header := range []string{"a:\n", "b:\n"} {
+			if n := strings.Count(got, header); n != 1 {
+				t.Errorf("%s: want one header %q, got %d:\n%s", order, header, n, got)
+			}
+		}
+		if n := strings.Count(got, "oh no"); n != 1 {
+			t.Errorf("%s: want failing output shown once, got %d:\n%s", order, n, got)
+		}
+	}
+}
+
+func TestFilter_MergesRepeatedPackageWhenSortingPackages(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"a","Test":"TestA1"}
+{"Action":"pass","Package":"a"}
+{"Action":"pass","Package":"b","Test":"TestB1"}
+{"Action":"pass","Package":"b"}
+{"Action":"fail","Package":"a","Test":"TestA2"}
+{"Action":"fail","Package":"a"}`),
+		Stdout:       buf,
+		Stderr:       io.Discard,
+		ShowOutput:   gotestdox.ShowOutputNone,
+		SortPackages: gotestdox.SortPackagesFailures,
+	}
+	td.Filter()
+	want := `a:
+ ✔ A1 (0.00s)
+ x A2 (0.00s)
+
+b:
+ ✔ B1 (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestSlowest_ReturnsNSlowestTestsInOrder(t *testing.T) {
+	t.Parallel()
+	tests := []gotestdox.Event{
+		{Test: "TestA", Elapsed: 0.1},
+		{Test: "TestB", Elapsed: 2},
+		{Test: "TestC", Elapsed: 0.5},
+		{Test: "TestD", Elapsed: 0.5},
+	}
+	var got []string
+	for _, test := range gotestdox.Slowest(tests, 3) {
+		got = append(got, test.Test)
+	}
+	want := []string{"TestB", "TestC", "TestD"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if len(gotestdox.Slowest(tests, 10)) != 4 {
+		t.Error("want all tests when n exceeds their number")
+	}
+}
+
+func TestFilter_ListsSlowestTestsAcrossPackagesWhenRequested(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"a","Test":"TestQuick","Elapsed":0.01}
+{"Action":"pass","Package":"a","Test":"TestSlow","Elapsed":1.5}
+{"Action":"pass","Package":"a"}
+{"Action":"fail","Package":"b","Test":"TestSlowest","Elapsed":3}
+{"Action":"pass","Package":"b","Test":"TestMedium","Elapsed":0.2}
+{"Action":"fail","Package":"b"}`),
+		Stdout:           buf,
+		Stderr:           io.Discard,
+		NoPackageHeaders: true,
+		ShowOutput:       gotestdox.ShowOutputNone,
+		Dots:             true,
+		TopSlowest:       3,
+	}
+	td.Filter()
+	want := `Slowest tests:
+  1. 3.00s b: Slowest
+  2. 1.50s a: Slow
+  3. 0.20s b: Medium
+`
+	got := buf.String()
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("want output to end with slowest tests:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestFilter_SortsPackagesByFailuresWhenRequested(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"a","Test":"TestA"}
+{"Action":"pass","Package":"a"}
+{"Action":"fail","Package":"d","Test":"TestA"}
+{"Action":"fail","Package":"d"}
+{"Action":"fail","Package":"c","Test":"TestA"}
+{"Action":"fail","Package":"c","Test":"TestB"}
+{"Action":"fail","Package":"c"}
+{"Action":"fail","Package":"b","Test":"TestA"}
+{"Action":"fail","Package":"b"}`),
+		Stdout:           buf,
+		Stderr:           io.Discard,
+		NoPackageHeaders: true,
+		ShowOutput:       gotestdox.ShowOutputNone,
+		SortPackages:     gotestdox.SortPackagesFailures,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, line := range strings.Split(buf.String(), "\n\n") {
+		if line != "" {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		" x A (0.00s)\n x B (0.00s)",
+		" x A (0.00s)",
+		" x A (0.00s)",
+		" ✔ A (0.00s)",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	pkgs := []string{}
+	for _, pkg := range result.Packages {
+		pkgs = append(pkgs, pkg.Package)
+	}
+	if !cmp.Equal([]string{"a", "d", "c", "b"}, pkgs) {
+		t.Errorf("want packages recorded in arrival order, got %v", pkgs)
+	}
+}
+
+func TestFilter_GivesUpIfNoInputArrivesBeforeTimeout(t *testing.T) {
+	t.Parallel()
+	r, w := io.Pipe()
+	defer w.Close()
+	td := gotestdox.TestDoxer{
+		Stdin:  gotestdox.WithTimeout(r, 10*time.Millisecond),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := td.Filter()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if result.OK {
+		t.Error("want not ok")
+	}
+	want := "no input received after 10ms"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("want %q in error, got %q", want, err)
+	}
+}
+
+func TestFilter_ReturnsErrorIfResultsCannotBeWritten(t *testing.T) {
+	t.Parallel()
+	pr, pw := io.Pipe()
+	pr.CloseWithError(errors.New("disk full"))
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p"}`),
+		Stdout: pw,
+		Stderr: io.Discard,
+	}
+	result, err := td.Filter()
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("want write error, got %v", err)
+	}
+	if result.OK {
+		t.Error("want not OK")
+	}
+}
+
+func TestFilter_ReturnsNoErrorForTestFailures(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p"}`),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK || result.Failed != 1 {
+		t.Errorf("want 1 failure and not OK, got %+v", result)
+	}
+}
+
+func TestExecGoTest_ReturnsErrorIfGoCannotBeRun(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+		GoCommand: filepath.Join(t.TempDir(), "no-such-go"),
+	}
+	_, err := td.ExecGoTest(nil)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if td.OK {
+		t.Error("want not OK")
+	}
+}
+
+func TestWithTimeout_AllowsSlowInputOnceSomeHasArrived(t *testing.T) {
+	t.Parallel()
+	r, w := io.Pipe()
+	go func() {
+		fmt.Fprintln(w, "first")
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintln(w, "second")
+		w.Close()
+	}()
+	got, err := io.ReadAll(gotestdox.WithTimeout(r, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "first\nsecond\n"
+	if want != string(got) {
+		t.Error(cmp.Diff(want, string(got)))
+	}
+}
+
+func TestWithTimeout_ReadsEmptyInputWithoutError(t *testing.T) {
+	t.Parallel()
+	got, err := io.ReadAll(gotestdox.WithTimeout(strings.NewReader(""), time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no data, got %q", got)
+	}
+}
+
+func TestFilter_ReportsPackageAsSkippedOnlyIfNoTestsRan(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"skip","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}
+{"Action":"skip","Package":"q","Test":"TestB"}
+{"Action":"pass","Package":"q","Test":"TestC"}
+{"Action":"pass","Package":"q"}`),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := td.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, pkg := range result.Packages {
+		got = append(got, pkg.Package+" "+pkg.Action)
+	}
+	want := []string{"p skip", "q pass"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if !result.OK {
+		t.Error("want ok")
+	}
+}
+
+func TestFilter_ColoursOutputWhenColorIsAlways(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = true }()
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+		Color:  gotestdox.ColorAlways,
+	}
+	td.Filter()
+	want := color.New(color.FgGreen).Sprint("✔")
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want coloured %q in %q", want, buf.String())
+	}
+}
+
+func TestMatchPackage_MatchesGlobPatternsAgainstImportPaths(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		pattern, pkg string
+		want         bool
+	}{
+		{"github.com/octocat/mymodule", "github.com/octocat/mymodule", true},
+		{"github.com/octocat/*", "github.com/octocat/mymodule", true},
+		{"github.com/octocat/*", "github.com/octocat/mymodule/api", false},
+		{"github.com/octocat/**", "github.com/octocat/mymodule/api", true},
+		{"internal/**", "github.com/octocat/mymodule/internal/api", true},
+		{"internal/**", "github.com/octocat/mymodule/internal", true},
+		{"internal/**", "github.com/octocat/mymodule/api", false},
+		{"**/api", "github.com/octocat/mymodule/api", true},
+		{"api", "github.com/octocat/mymodule/api", true},
+		{"api", "github.com/octocat/mymodule/apiv2", false},
+		{"github.com/**/util", "github.com/octocat/mymodule/internal/util", true},
+		{"[", "github.com/octocat/mymodule", false},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.MatchPackage(tc.pattern, tc.pkg)
+		if tc.want != got {
+			t.Errorf("MatchPackage(%q, %q): want %t, got %t", tc.pattern, tc.pkg, tc.want, got)
+		}
+	}
+}
+
+func TestVersion_IncludesGoVersion(t *testing.T) {
+	t.Parallel()
+	got := gotestdox.Version()
+	if !strings.Contains(got, runtime.Version()) {
+		t.Errorf("want Go version %q in %q", runtime.Version(), got)
+	}
+}
+
 func ExampleTestDoxer_Filter() {
 	input := `{"Action":"pass","Package":"demo","Test":"TestItWorks"}
 	{"Action":"pass","Package":"demo","Elapsed":0}`
@@ -234,6 +1389,18 @@ func ExampleTestDoxer_Filter() {
 	//  ✔ It works (0.00s)
 }
 
+func ExampleTestDoxer_List() {
+	input := `TestItWorks
+TestParseJSON_ReturnsError
+ok  	demo	0.003s`
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(input)
+	td.List()
+	// Output:
+	// It works
+	// ParseJSON returns error
+}
+
 func ExampleEvent_String() {
 	event := gotestdox.Event{
 		Action:   "pass",
@@ -273,5 +1440,5 @@ func ExampleParseJSON() {
 	}
 	fmt.Printf("%#v\n", event)
 	// Output:
-	// gotestdox.Event{Action:"pass", Package:"demo", Test:"TestItWorks", Sentence:"", Output:"", Elapsed:0.2}
+	// gotestdox.Event{Action:"pass", Package:"demo", Test:"TestItWorks", Sentence:"", Output:"", Elapsed:0.2, Category:"", Time:time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Retry:0, New:false}
 }
@@ -0,0 +1,90 @@
+package gotestdox
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+)
+
+// HTMLFormatter prints results as a self-contained HTML page, for sharing with
+// people who'd rather not read a terminal. Each package is a collapsible
+// section, open if the package failed, containing a coloured line for each
+// test. The output of a failing test can be shown by expanding its line. The
+// page needs no external assets, since its styles are included inline.
+type HTMLFormatter struct {
+	started        bool
+	passed, failed int
+}
+
+// htmlStyle is the stylesheet included in the page produced by
+// [HTMLFormatter].
+const htmlStyle = `body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+details.package { margin: 0.5em 0; }
+details.package > summary { font-weight: bold; cursor: pointer; }
+ul { list-style: none; padding-left: 1.5em; margin: 0.25em 0; }
+li { margin: 0.15em 0; }
+.pass .status { color: #2a8a2a; }
+.fail .status { color: #c62828; }
+.skip .status { color: #888; }
+.elapsed { color: #888; }
+li summary { cursor: pointer; }
+pre { background: #f6f6f6; padding: 0.5em; overflow-x: auto; }
+`
+
+// start returns the beginning of the page, if it hasn't already been printed.
+func (f *HTMLFormatter) start() string {
+	if f.started {
+		return ""
+	}
+	f.started = true
+	return "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n" +
+		"<title>Test results</title>\n<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n<h1>Test results</h1>\n"
+}
+
+// Header starts a collapsible section for the package.
+func (f *HTMLFormatter) Header(pkg Event) ([]byte, error) {
+	class, status := "pass", "✔"
+	switch {
+	case pkg.Failed():
+		class, status = "fail", "x"
+	case pkg.Skipped():
+		class, status = "skip", "-"
+	}
+	open := ""
+	if pkg.Failed() {
+		open = " open"
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(f.start())
+	fmt.Fprintf(buf, "<details class=\"package %s\"%s>\n", class, open)
+	fmt.Fprintf(buf, "<summary><span class=\"status\">%s</span> %s <span class=\"elapsed\">(%.2fs)</span></summary>\n<ul>\n", status, html.EscapeString(pkg.Package), pkg.Elapsed)
+	return buf.Bytes(), nil
+}
+
+// Format prints a list item for the test result. If the test failed and has
+// any output, the output can be shown by expanding the item.
+func (f *HTMLFormatter) Format(test Event) ([]byte, error) {
+	class, status := "fail", "x"
+	if test.Passed() {
+		class, status = "pass", "✔"
+		f.passed++
+	} else {
+		f.failed++
+	}
+	line := fmt.Sprintf("<span class=\"status\">%s</span> %s <span class=\"elapsed\">(%.2fs)</span>", status, html.EscapeString(test.Sentence), test.Elapsed)
+	if !test.Failed() || test.Output == "" {
+		return []byte(fmt.Sprintf("<li class=\"%s\">%s</li>\n", class, line)), nil
+	}
+	return []byte(fmt.Sprintf("<li class=\"%s\"><details><summary>%s</summary>\n<pre>%s</pre>\n</details></li>\n", class, line, html.EscapeString(test.Output))), nil
+}
+
+// Footer ends the package's section.
+func (f *HTMLFormatter) Footer(pkg Event) ([]byte, error) {
+	return []byte("</ul>\n</details>\n"), nil
+}
+
+// Finish prints the total numbers of tests passed and failed, and ends the
+// page.
+func (f *HTMLFormatter) Finish() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s<p class=\"totals\">%d passed, %d failed</p>\n</body>\n</html>\n", f.start(), f.passed, f.failed)), nil
+}
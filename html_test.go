@@ -0,0 +1,66 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+)
+
+func TestHTMLFormatter_ProducesSelfContainedPageWithSectionPerPackage(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.HTMLFormatter{}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Sentence: "Parses <input>", Elapsed: 0.5},
+			{Action: "fail", Sentence: "Rejects bad input", Output: "    p_test.go:12: want error & got nil\n"},
+		},
+		"q": {{Action: "pass", Sentence: "Works"}},
+	}, "p", "q")
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		`<details class="package pass">`,
+		`<summary><span class="status">✔</span> p <span class="elapsed">(0.00s)</span></summary>`,
+		`<li class="pass"><span class="status">✔</span> Parses &lt;input&gt; <span class="elapsed">(0.50s)</span></li>`,
+		`<li class="fail"><details><summary><span class="status">x</span> Rejects bad input`,
+		"<pre>    p_test.go:12: want error &amp; got nil\n</pre>",
+		`<p class="totals">2 passed, 1 failed</p>`,
+		"</html>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in output:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "<!DOCTYPE html>") != 1 {
+		t.Errorf("want page started only once, got:\n%s", got)
+	}
+	if strings.Contains(got, "<link") || strings.Contains(got, "<script src") {
+		t.Errorf("want no external assets, got:\n%s", got)
+	}
+}
+
+func TestHTMLFormatter_OpensSectionsForFailingPackages(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.HTMLFormatter{}
+	data, err := f.Header(gotestdox.Event{Action: "fail", Package: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<details class="package fail" open>`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("want %q in output:\n%s", want, data)
+	}
+}
+
+func TestHTMLFormatter_ProducesPageEvenWithNoResults(t *testing.T) {
+	t.Parallel()
+	data, err := (&gotestdox.HTMLFormatter{}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if !strings.HasPrefix(got, "<!DOCTYPE html>") || !strings.HasSuffix(got, "</html>\n") {
+		t.Errorf("want complete page, got:\n%s", got)
+	}
+}
@@ -0,0 +1,92 @@
+package gotestdox
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Hyperlink returns text wrapped in the OSC 8 escape sequence that makes it
+// a link to target, in terminals that support it. Other terminals just show
+// text.
+func Hyperlink(target, text string) string {
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// fileLocation matches a reference to a line of a Go source file, such as
+// "parser_test.go:12", or "/src/parser/parser.go:40" in a stack trace.
+var fileLocation = regexp.MustCompile(`[\w./-]*\w\.go:\d+`)
+
+// linkLocations makes each file location in output a hyperlink to the file,
+// using a file:// URL. Relative filenames, such as those in messages logged
+// by [testing.T], are resolved against dir, or against the current directory
+// if dir is empty.
+func linkLocations(output, dir string) string {
+	return fileLocation.ReplaceAllStringFunc(output, func(location string) string {
+		file := location[:strings.LastIndex(location, ":")]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return location
+		}
+		target := &url.URL{Scheme: "file", Path: filepath.ToSlash(file)}
+		return Hyperlink(target.String(), location)
+	})
+}
+
+// packageDirs returns a function giving the source directory of a package,
+// as reported by 'go list', for resolving the filenames in its test output.
+// Each package is looked up only once. If the directory can't be found, the
+// function returns the empty string.
+func (td *TestDoxer) packageDirs() func(pkg string) string {
+	dirs := map[string]string{}
+	return func(pkg string) string {
+		dir, ok := dirs[pkg]
+		if !ok {
+			out, err := exec.Command(td.goCommand(), "list", "-f", "{{.Dir}}", pkg).Output()
+			if err == nil {
+				dir = strings.TrimSpace(string(out))
+			}
+			dirs[pkg] = dir
+		}
+		return dir
+	}
+}
+
+// hyperlinks returns the functions to be used as [TextFormatter.PackageDir],
+// if td.Hyperlinks is set, and [TextFormatter.PackageLink], if
+// td.PackageLinks is set. Both are nil unless td.Stdout is a terminal.
+func (td *TestDoxer) hyperlinks() (locations, packages func(pkg string) string) {
+	if !td.Hyperlinks && !td.PackageLinks {
+		return nil, nil
+	}
+	f, ok := td.Stdout.(*os.File)
+	if !ok || !isatty.IsTerminal(f.Fd()) {
+		return nil, nil
+	}
+	dirs := td.packageDirs()
+	if td.Hyperlinks {
+		locations = dirs
+	}
+	if td.PackageLinks {
+		packages = dirs
+	}
+	return locations, packages
+}
+
+// linkDir returns text as a hyperlink to the directory dir, or just text if
+// dir is empty.
+func linkDir(dir, text string) string {
+	if dir == "" {
+		return text
+	}
+	target := &url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}
+	return Hyperlink(target.String(), text)
+}
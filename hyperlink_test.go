@@ -0,0 +1,63 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHyperlink_WrapsTextInOSC8EscapeSequence(t *testing.T) {
+	t.Parallel()
+	want := "\x1b]8;;file:///src/p/p_test.go\x1b\\p_test.go:12\x1b]8;;\x1b\\"
+	got := gotestdox.Hyperlink("file:///src/p/p_test.go", "p_test.go:12")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatterWithPackageDir_LinksFileLocationsInOutput(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{
+		PackageDir: func(pkg string) string {
+			return "/src/" + pkg
+		},
+	}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{
+			Action:   "fail",
+			Package:  "p",
+			Sentence: "Parses input",
+			Output:   "    p_test.go:12: want 1, got 2\n\t/usr/lib/go/src/testing/testing.go:1690 +0x1f\nno location here.go\n",
+		}},
+	}, "p")
+	want := "p:\n x Parses input (0.00s)\n" +
+		"    " + gotestdox.Hyperlink("file:///src/p/p_test.go", "p_test.go:12") + ": want 1, got 2\n" +
+		"\t" + gotestdox.Hyperlink("file:///usr/lib/go/src/testing/testing.go", "/usr/lib/go/src/testing/testing.go:1690") + " +0x1f\n" +
+		"    no location here.go\n\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextFormatterWithPackageLink_LinksPackageNamesInHeaders(t *testing.T) {
+	color.NoColor = true
+	f := &gotestdox.TextFormatter{
+		PackageLink: func(pkg string) string {
+			if pkg == "unknown" {
+				return ""
+			}
+			return "/src/" + pkg
+		},
+	}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p":       {{Action: "pass", Sentence: "Parses input"}},
+		"unknown": {{Action: "pass", Sentence: "Works"}},
+	}, "p", "unknown")
+	want := gotestdox.Hyperlink("file:///src/p", "p") + ":\n ✔ Parses input (0.00s)\n\n" +
+		"unknown:\n ✔ Works (0.00s)\n\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
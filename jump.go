@@ -0,0 +1,170 @@
+package gotestdox
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DefaultEditorCommand is the template used for [TestDoxer.EditorCommand]
+// if none is given.
+const DefaultEditorCommand = "vim +{{.Line}} {{.File}}"
+
+// A Location is a line in a source file, such as where a test failed.
+type Location struct {
+	File string
+	Line int
+}
+
+// FailureLocation returns the first file location mentioned in the output of
+// the test event, such as 'parser_test.go:42' in a message logged by
+// [testing.T.Errorf], and true, or false if there is none. Locations in test
+// files are preferred, so that the result is where the failure was reported,
+// rather than, for example, the top of a panic's stack trace. The filename is
+// as it appears in the output, which is usually relative to the directory of
+// the test's package.
+func FailureLocation(event Event) (Location, bool) {
+	var found []Location
+	for _, match := range fileLocation.FindAllString(event.Output, -1) {
+		i := strings.LastIndex(match, ":")
+		line, err := strconv.Atoi(match[i+1:])
+		if err != nil {
+			continue
+		}
+		found = append(found, Location{File: match[:i], Line: line})
+	}
+	for _, loc := range found {
+		if strings.HasSuffix(loc.File, "_test.go") {
+			return loc, true
+		}
+	}
+	if len(found) == 0 {
+		return Location{}, false
+	}
+	return found[0], true
+}
+
+// ParseEditorCommand parses text as a template for
+// [TestDoxer.EditorCommand], and checks that it can be executed with a
+// [Location], returning an error if not.
+func ParseEditorCommand(text string) (*template.Template, error) {
+	tmpl, err := template.New("editor").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, Location{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// printJump prints to td.Stderr the command to open an editor at the location
+// of the first of failures that has one (see [FailureLocation]), made from
+// td.EditorCommand, or [DefaultEditorCommand] if that's nil. A relative
+// filename is resolved against the directory of the test's package, if that
+// can be found, and then made relative to the current directory, if
+// possible, and it's quoted for the shell if necessary.
+func (td *TestDoxer) printJump(failures []Event) {
+	tmpl := td.EditorCommand
+	if tmpl == nil {
+		tmpl = template.Must(ParseEditorCommand(DefaultEditorCommand))
+	}
+	for _, event := range failures {
+		loc, ok := FailureLocation(event)
+		if !ok {
+			continue
+		}
+		loc.File = shellQuote(td.jumpFile(event.Package, loc.File))
+		cmd := &bytes.Buffer{}
+		if err := tmpl.Execute(cmd, loc); err != nil {
+			fmt.Fprintln(td.Stderr, "editor command:", err)
+			return
+		}
+		fmt.Fprintln(td.Stderr, cmd.String())
+		return
+	}
+}
+
+// jumpFile returns the path to use for file, as mentioned in the output of a
+// test in pkg, when opening it in an editor (see printJump).
+func (td *TestDoxer) jumpFile(pkg, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	dir := td.packageDirs()(pkg)
+	if dir == "" {
+		return file
+	}
+	path := filepath.Join(dir, file)
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// printFailuresByFile lists failures again, grouped by the file where each
+// was reported (see [FailureLocation]), with the files that have the most
+// failures first, and each file's failures in order of line number. Files
+// with equal numbers of failures are in alphabetical order. Failures with no
+// location are listed last.
+func (td *TestDoxer) printFailuresByFile(failures []Event) {
+	if len(failures) == 0 {
+		return
+	}
+	type located struct {
+		test Event
+		line int
+	}
+	groups := map[string][]located{}
+	files := []string{}
+	var unlocated []Event
+	for _, test := range failures {
+		loc, ok := FailureLocation(test)
+		if !ok {
+			unlocated = append(unlocated, test)
+			continue
+		}
+		file := td.jumpFile(test.Package, loc.File)
+		if _, ok := groups[file]; !ok {
+			files = append(files, file)
+		}
+		groups[file] = append(groups[file], located{test: test, line: loc.Line})
+	}
+	slices.SortFunc(files, func(a, b string) int {
+		if n := len(groups[b]) - len(groups[a]); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	list := td.failureList(len(failures))
+	fmt.Fprintln(list.w, "Failures by file:")
+	for _, file := range files {
+		if list.full() {
+			break
+		}
+		tests := groups[file]
+		slices.SortStableFunc(tests, func(a, b located) int {
+			return a.line - b.line
+		})
+		fmt.Fprintf(list.w, "%s (%d):\n", file, len(tests))
+		for _, t := range tests {
+			list.entry(" x line %d: %s\n", t.line, t.test.Sentence)
+		}
+	}
+	if len(unlocated) > 0 && !list.full() {
+		fmt.Fprintln(list.w, "no location:")
+		for _, test := range unlocated {
+			list.entry(" x %s: %s\n", test.Package, test.Sentence)
+		}
+	}
+	list.finish()
+}
@@ -0,0 +1,135 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFailureLocation_PrefersLocationsInTestFiles(t *testing.T) {
+	t.Parallel()
+	event := gotestdox.Event{
+		Output: "panic: oh no\n\t/usr/local/go/src/testing/testing.go:1576 +0x10\n\t/src/p/parser_test.go:42 +0x20\n",
+	}
+	want := gotestdox.Location{File: "/src/p/parser_test.go", Line: 42}
+	got, ok := gotestdox.FailureLocation(event)
+	if !ok {
+		t.Fatal("want location")
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFailureLocation_ReturnsFirstLocationIfNoneIsInATestFile(t *testing.T) {
+	t.Parallel()
+	event := gotestdox.Event{Output: "    helper.go:7: bad input\n    other.go:9: also bad\n"}
+	want := gotestdox.Location{File: "helper.go", Line: 7}
+	got, ok := gotestdox.FailureLocation(event)
+	if !ok {
+		t.Fatal("want location")
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFailureLocation_ReturnsFalseIfOutputHasNoLocation(t *testing.T) {
+	t.Parallel()
+	_, ok := gotestdox.FailureLocation(gotestdox.Event{Output: "exit status 1\n"})
+	if ok {
+		t.Error("want false")
+	}
+}
+
+func TestParseEditorCommand_ErrorsOnUnknownField(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.ParseEditorCommand("vim +{{.Column}} {{.File}}")
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestFilter_PrintsEditorCommandForFirstFailureWithALocation(t *testing.T) {
+	t.Parallel()
+	tmpl, err := gotestdox.ParseEditorCommand("code -g {{.File}}:{{.Line}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"output","Package":"example.com/nonexistent","Test":"TestA","Output":"exit status 1\n"}
+{"Action":"fail","Package":"example.com/nonexistent","Test":"TestA"}
+{"Action":"output","Package":"example.com/nonexistent","Test":"TestB","Output":"    parser_test.go:423: oh no\n"}
+{"Action":"fail","Package":"example.com/nonexistent","Test":"TestB"}
+{"Action":"fail","Package":"example.com/nonexistent"}`),
+		Stdout:           io.Discard,
+		Stderr:           stderr,
+		FirstFailureJump: true,
+		EditorCommand:    tmpl,
+	}
+	if _, err := td.Filter(); err != nil {
+		t.Fatal(err)
+	}
+	want := "code -g parser_test.go:423\n"
+	if want != stderr.String() {
+		t.Error(cmp.Diff(want, stderr.String()))
+	}
+}
+
+func TestFilter_PrintsNoEditorCommandIfAllTestsPassed(t *testing.T) {
+	t.Parallel()
+	stderr := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}`),
+		Stdout:           io.Discard,
+		Stderr:           stderr,
+		FirstFailureJump: true,
+	}
+	if _, err := td.Filter(); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("want nothing on stderr, got %q", stderr)
+	}
+}
+
+func TestFilter_ListsFailuresByFileWithMostFailuresFirst(t *testing.T) {
+	t.Parallel()
+	stderr := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"output","Package":"example.com/nonexistent","Test":"TestB","Output":"    b_test.go:7: oh no\n"}
+{"Action":"fail","Package":"example.com/nonexistent","Test":"TestB"}
+{"Action":"output","Package":"example.com/nonexistent","Test":"TestC","Output":"    c_test.go:30: oh no\n"}
+{"Action":"fail","Package":"example.com/nonexistent","Test":"TestC"}
+{"Action":"output","Package":"example.com/nonexistent","Test":"TestA","Output":"    a_test.go:5: oh no\n"}
+{"Action":"fail","Package":"example.com/nonexistent","Test":"TestA"}
+{"Action":"output","Package":"example.com/nonexistent","Test":"TestD","Output":"    c_test.go:12: oh no\n"}
+{"Action":"fail","Package":"example.com/nonexistent","Test":"TestD"}
+{"Action":"fail","Package":"example.com/nonexistent"}`),
+		Stdout:              io.Discard,
+		Stderr:              stderr,
+		Formatter:           &gotestdox.TAPFormatter{},
+		GroupFailuresByFile: true,
+	}
+	if _, err := td.Filter(); err != nil {
+		t.Fatal(err)
+	}
+	want := `Failures by file:
+c_test.go (2):
+ x line 12: D
+ x line 30: C
+a_test.go (1):
+ x line 5: A
+b_test.go (1):
+ x line 7: B
+`
+	if want != stderr.String() {
+		t.Error(cmp.Diff(want, stderr.String()))
+	}
+}
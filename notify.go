@@ -0,0 +1,33 @@
+package gotestdox
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// notify shows a desktop notification summarising the results of the run, if
+// there's a way to do that on this system: 'osascript' on macOS, or
+// 'notify-send' elsewhere. If not, or if the notification fails, it does
+// nothing, since the results have already been printed.
+func (td *TestDoxer) notify() {
+	title := "gotestdox: tests passed"
+	if !td.OK {
+		title = "gotestdox: tests failed"
+	}
+	message := fmt.Sprintf("%d passed, %d failed", td.Passed, td.Failed)
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+		cmd = exec.Command("osascript", "-e", script)
+	default:
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return
+		}
+		cmd = exec.Command(path, title, message)
+	}
+	cmd.Run()
+}
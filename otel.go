@@ -0,0 +1,213 @@
+package gotestdox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// OTLPFormatter prints results as OpenTelemetry trace data, in the OTLP/JSON
+// encoding, so that a test run can be explored in a tracing UI. The whole run
+// is a single trace, with a span for each package, and a child span for each
+// test in it, named by its sentence. A span's status is OK if the test or
+// package passed, or Error if it failed.
+//
+// Since the JSON records from 'go test' give only the elapsed time for each
+// test, every span is treated as ending at the time its package result was
+// formatted. Nothing is printed until Finish is called.
+type OTLPFormatter struct {
+	traceID string
+	pkg     *otlpSpan
+	spans   []otlpSpan
+	end     time.Time
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+// Span kind and status codes, as defined by the OTLP specification.
+const (
+	otlpKindInternal = 1
+	otlpStatusOK     = 1
+	otlpStatusError  = 2
+)
+
+// Header starts a span for the package.
+func (f *OTLPFormatter) Header(pkg Event) ([]byte, error) {
+	if f.traceID == "" {
+		f.traceID = randomID(16)
+	}
+	f.end = time.Now()
+	span := f.span(pkg, pkg.Package, "")
+	f.pkg = &span
+	return nil, nil
+}
+
+// Format adds a span for the test result, as a child of the package's span.
+func (f *OTLPFormatter) Format(test Event) ([]byte, error) {
+	f.spans = append(f.spans, f.span(test, test.Sentence, f.pkg.SpanID))
+	return nil, nil
+}
+
+// Footer completes the package's span.
+func (f *OTLPFormatter) Footer(pkg Event) ([]byte, error) {
+	f.spans = append(f.spans, *f.pkg)
+	return nil, nil
+}
+
+// Finish prints the trace data for all the spans.
+func (f *OTLPFormatter) Finish() ([]byte, error) {
+	type scope struct {
+		Name string `json:"name"`
+	}
+	type scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	type resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	type resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	spans := f.spans
+	if spans == nil {
+		spans = []otlpSpan{}
+	}
+	data, err := json.Marshal(struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{
+				Attributes: []otlpAttribute{attribute("service.name", "gotestdox")},
+			},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: "github.com/bitfield/gotestdox"},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func (f *OTLPFormatter) span(e Event, name, parent string) otlpSpan {
+	start := f.end.Add(-time.Duration(e.Elapsed * float64(time.Second)))
+	status := otlpStatusOK
+	if e.Failed() {
+		status = otlpStatusError
+	}
+	attrs := []otlpAttribute{
+		attribute("test.package", e.Package),
+		attribute("test.action", e.Action),
+	}
+	if e.Test != "" {
+		attrs = append(attrs, attribute("test.name", e.Test))
+	}
+	return otlpSpan{
+		TraceID:           f.traceID,
+		SpanID:            randomID(8),
+		ParentSpanID:      parent,
+		Name:              name,
+		Kind:              otlpKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(f.end.UnixNano(), 10),
+		Attributes:        attrs,
+		Status:            otlpStatus{Code: status},
+	}
+}
+
+func attribute(key, value string) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	a.Value.StringValue = value
+	return a
+}
+
+// randomID returns a random identifier of n bytes, hex-encoded, as used for
+// OTLP trace and span IDs.
+func randomID(n int) string {
+	id := make([]byte, n)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// OTLPExporter is an [io.Writer] that sends the trace data produced by an
+// [OTLPFormatter] to an OpenTelemetry collector, using OTLP over HTTP. Since
+// the trace data is for information only, an export failure doesn't count as
+// a test failure: instead, it's reported to Stderr, and the data is
+// discarded.
+type OTLPExporter struct {
+	Endpoint string
+	Stderr   io.Writer
+	Client   *http.Client
+}
+
+// NewOTLPExporter returns an [*OTLPExporter] that sends trace data to the
+// collector at endpoint, such as 'http://localhost:4318'. If endpoint has no
+// path, the standard path '/v1/traces' is used.
+func NewOTLPExporter(endpoint string, stderr io.Writer) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("want http or https URL, got %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return &OTLPExporter{
+		Endpoint: u.String(),
+		Stderr:   stderr,
+		Client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Write sends data to e.Endpoint. It always reports success, so as not to
+// interrupt the processing of test results, but any error is reported to
+// e.Stderr.
+func (e *OTLPExporter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	resp, err := e.Client.Post(e.Endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintln(e.Stderr, "exporting spans:", err)
+		return len(data), nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		fmt.Fprintf(e.Stderr, "exporting spans: %s returned %s\n", e.Endpoint, resp.Status)
+	}
+	return len(data), nil
+}
@@ -0,0 +1,123 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+type otlpTrace struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []struct {
+				TraceID, SpanID, ParentSpanID, Name string
+				StartTimeUnixNano, EndTimeUnixNano  string
+				Status                              struct{ Code int }
+			}
+		}
+	}
+}
+
+func TestOTLPFormatter_PrintsSpanForEachTestAsChildOfPackageSpan(t *testing.T) {
+	t.Parallel()
+	f := &gotestdox.OTLPFormatter{}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {
+			{Action: "pass", Package: "p", Sentence: "A", Elapsed: 0.5},
+			{Action: "fail", Package: "p", Sentence: "B"},
+		},
+	}, "p")
+	trace := otlpTrace{}
+	if err := json.Unmarshal([]byte(got), &trace); err != nil {
+		t.Fatal(err)
+	}
+	spans := trace.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("want 3 spans, got %d", len(spans))
+	}
+	a, b, pkg := spans[0], spans[1], spans[2]
+	names := []string{a.Name, b.Name, pkg.Name}
+	if !cmp.Equal([]string{"A", "B", "p"}, names) {
+		t.Error(cmp.Diff([]string{"A", "B", "p"}, names))
+	}
+	if a.ParentSpanID != pkg.SpanID || b.ParentSpanID != pkg.SpanID {
+		t.Errorf("want test spans to have parent %q, got %q and %q", pkg.SpanID, a.ParentSpanID, b.ParentSpanID)
+	}
+	if pkg.ParentSpanID != "" {
+		t.Errorf("want no parent for package span, got %q", pkg.ParentSpanID)
+	}
+	if a.TraceID != pkg.TraceID || len(pkg.TraceID) != 32 {
+		t.Errorf("want all spans in one trace, got IDs %q and %q", a.TraceID, pkg.TraceID)
+	}
+	if a.Status.Code != 1 || b.Status.Code != 2 {
+		t.Errorf("want status codes 1 (OK) and 2 (Error), got %d and %d", a.Status.Code, b.Status.Code)
+	}
+	start, _ := strconv.ParseInt(a.StartTimeUnixNano, 10, 64)
+	end, _ := strconv.ParseInt(a.EndTimeUnixNano, 10, 64)
+	if end-start != 5e8 {
+		t.Errorf("want span duration 0.5s, got %dns", end-start)
+	}
+}
+
+func TestOTLPExporter_PostsDataToTracesEndpoint(t *testing.T) {
+	t.Parallel()
+	var path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+	stderr := &bytes.Buffer{}
+	e, err := gotestdox.NewOTLPExporter(srv.URL, stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.Write([]byte(`{"resourceSpans":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/traces" {
+		t.Errorf("want path /v1/traces, got %q", path)
+	}
+	if body != `{"resourceSpans":[]}` {
+		t.Errorf("unexpected body %q", body)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("unexpected error %q", stderr)
+	}
+}
+
+func TestOTLPExporter_ReportsErrorWithoutFailingWhenEndpointUnreachable(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	stderr := &bytes.Buffer{}
+	e, err := gotestdox.NewOTLPExporter(srv.URL, stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.Write([]byte("{}"))
+	if err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	if !strings.HasPrefix(stderr.String(), "exporting spans:") {
+		t.Errorf("want export error reported, got %q", stderr)
+	}
+}
+
+func TestNewOTLPExporter_ErrorsOnNonHTTPEndpoint(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.NewOTLPExporter("localhost:4318", io.Discard)
+	if err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,196 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Unowned is the owner that [TestDoxer.OwnerOf] reports for a package that
+// no rule in the CODEOWNERS file matches.
+const Unowned = "unowned"
+
+// Codeowners holds the rules from a CODEOWNERS file, as used by GitHub and
+// GitLab to say who is responsible for each part of a repository.
+type Codeowners struct {
+	rules []ownerRule
+}
+
+// ownerRule is one line of a CODEOWNERS file: a pattern, split into
+// segments, and the owners of the paths that match it.
+type ownerRule struct {
+	segments []string
+	anchored bool
+	dirOnly  bool
+	owners   []string
+}
+
+// ParseCodeowners reads the rules from a CODEOWNERS file. Each line gives a
+// pattern, in the style of .gitignore, followed by the owners (such as
+// '@octocat/api') of the paths that match it. Blank lines and comments,
+// beginning with '#', are ignored. It returns an error only if r can't be
+// read.
+func ParseCodeowners(r io.Reader) (Codeowners, error) {
+	c := Codeowners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pattern := fields[0]
+		rule := ownerRule{owners: fields[1:]}
+		rule.dirOnly = strings.HasSuffix(pattern, "/")
+		pattern = strings.Trim(pattern, "/")
+		// as in .gitignore, a slash anywhere but at the end anchors the
+		// pattern to the root of the repository
+		rule.anchored = strings.Contains(strings.TrimSuffix(fields[0], "/"), "/")
+		rule.segments = strings.Split(pattern, "/")
+		c.rules = append(c.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return Codeowners{}, err
+	}
+	return c, nil
+}
+
+// Of returns the owners of the file at name, a slash-separated path relative
+// to the root of the repository, according to the last rule that matches it,
+// as GitHub does. A rule matches a file if its pattern matches the file
+// itself, or any directory containing it, except that a pattern ending in
+// '/*', such as 'docs/*', matches only the files directly in that directory.
+// If no rule matches, or the last matching rule names no owners, Of returns
+// nil.
+func (c Codeowners) Of(name string) []string {
+	names := strings.Split(strings.Trim(path.Clean(name), "/"), "/")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if !c.rules[i].matches(names) {
+			continue
+		}
+		if len(c.rules[i].owners) == 0 {
+			return nil
+		}
+		return c.rules[i].owners
+	}
+	return nil
+}
+
+// matches reports whether r matches the file whose path has the segments
+// names.
+func (r ownerRule) matches(names []string) bool {
+	for end := 1; end <= len(names); end++ {
+		isDir := end < len(names)
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if isDir && r.segments[len(r.segments)-1] == "*" {
+			continue
+		}
+		if r.anchored {
+			if matchSegments(r.segments, names[:end]) {
+				return true
+			}
+			continue
+		}
+		if ok, err := path.Match(r.segments[0], names[end-1]); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// codeownersRoot returns the root of the repository containing the
+// CODEOWNERS file at name, which may be in the root itself, or in its
+// '.github' or 'docs' directory.
+func codeownersRoot(name string) string {
+	dir := filepath.Dir(name)
+	switch filepath.Base(dir) {
+	case ".github", "docs":
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// ownersOf reads the CODEOWNERS file at name, and returns a function, for
+// use as [TestDoxer.OwnerOf], that gives the owners of a package's source
+// directory (as reported by 'go list'), or [Unowned]. Since CODEOWNERS
+// patterns match files, a package is represented by a Go file in its
+// directory, so that a rule for '*.go' applies to every package.
+func (td *TestDoxer) ownersOf(name string) (func(pkg string) string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	owners, err := ParseCodeowners(f)
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(codeownersRoot(name))
+	if err != nil {
+		return nil, err
+	}
+	dirs := td.packageDirs()
+	return func(pkg string) string {
+		dir := dirs(pkg)
+		if dir == "" {
+			return Unowned
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return Unowned
+		}
+		who := owners.Of(filepath.ToSlash(filepath.Join(rel, "x.go")))
+		if len(who) == 0 {
+			return Unowned
+		}
+		return strings.Join(who, " ")
+	}, nil
+}
+
+// printOwners lists failures again, grouped by their owners according to
+// td.OwnerOf, in alphabetical order of owner, with [Unowned] last. Within each
+// group, the tests are in the order they finished.
+func (td *TestDoxer) printOwners(failures []Event) {
+	if len(failures) == 0 {
+		return
+	}
+	groups := map[string][]Event{}
+	owners := []string{}
+	for _, test := range failures {
+		owner := td.OwnerOf(test.Package)
+		if _, ok := groups[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		groups[owner] = append(groups[owner], test)
+	}
+	slices.SortFunc(owners, func(a, b string) int {
+		switch {
+		case a == b:
+			return 0
+		case a == Unowned:
+			return 1
+		case b == Unowned:
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	list := td.failureList(len(failures))
+	fmt.Fprintln(list.w, "Failures by owner:")
+	for _, owner := range owners {
+		if list.full() {
+			break
+		}
+		fmt.Fprintln(list.w, owner+":")
+		for _, test := range groups[owner] {
+			list.entry(" x %s: %s\n", test.Package, test.Sentence)
+		}
+	}
+	list.finish()
+}
@@ -0,0 +1,133 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCodeownersOf_ReturnsOwnersFromLastMatchingRule(t *testing.T) {
+	t.Parallel()
+	c, err := gotestdox.ParseCodeowners(strings.NewReader(`# default owners
+*        @octocat/core
+
+/api/    @octocat/api # the public API
+docs/*   @octocat/docs
+**/testdata @octocat/qa
+vendor/
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := []struct {
+		name string
+		want []string
+	}{
+		{name: "main.go", want: []string{"@octocat/core"}},
+		{name: "api/server.go", want: []string{"@octocat/api"}},
+		{name: "api/v2/server.go", want: []string{"@octocat/api"}},
+		{name: "internal/api/x.go", want: []string{"@octocat/core"}},
+		{name: "docs/x.go", want: []string{"@octocat/docs"}},
+		{name: "docs/examples/x.go", want: []string{"@octocat/core"}},
+		{name: "parser/testdata/x.go", want: []string{"@octocat/qa"}},
+		{name: "vendor/x.go", want: nil},
+	}
+	for _, tc := range tcs {
+		got := c.Of(tc.name)
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestCodeownersOf_ReturnsNilWhenNoRuleMatches(t *testing.T) {
+	t.Parallel()
+	c, err := gotestdox.ParseCodeowners(strings.NewReader("/api/ @octocat/api\n*.js @octocat/web\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Of("parser/x.go"); got != nil {
+		t.Errorf("want nil, got %q", got)
+	}
+}
+
+func TestFilter_ListsFailuresByOwnerWhenOwnerOfIsSet(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"fail","Package":"example.com/web","Test":"TestRender"}
+{"Action":"fail","Package":"example.com/web"}
+{"Action":"fail","Package":"example.com/api","Test":"TestServe"}
+{"Action":"pass","Package":"example.com/api","Test":"TestStart"}
+{"Action":"fail","Package":"example.com/api"}
+{"Action":"fail","Package":"example.com/tools","Test":"TestLint"}
+{"Action":"fail","Package":"example.com/tools"}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+		OwnerOf: func(pkg string) string {
+			switch pkg {
+			case "example.com/api":
+				return "@octocat/api"
+			case "example.com/web":
+				return "@octocat/web"
+			}
+			return gotestdox.Unowned
+		},
+		NoPackageHeaders: true,
+	}
+	td.Filter()
+	want := ` x Render (0.00s)
+
+ x Serve (0.00s)
+ ✔ Start (0.00s)
+
+ x Lint (0.00s)
+
+Failures by owner:
+@octocat/api:
+ x example.com/api: Serve
+@octocat/web:
+ x example.com/web: Render
+unowned:
+ x example.com/tools: Lint
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_ListsAtMostFailSummaryLimitFailuresByOwner(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Action":"fail","Package":"example.com/api","Test":"TestServe"}
+{"Action":"fail","Package":"example.com/api","Test":"TestStart"}
+{"Action":"fail","Package":"example.com/api"}
+{"Action":"fail","Package":"example.com/tools","Test":"TestLint"}
+{"Action":"fail","Package":"example.com/tools"}`),
+		Stdout: io.Discard,
+		Stderr: buf,
+		OwnerOf: func(pkg string) string {
+			if pkg == "example.com/api" {
+				return "@octocat/api"
+			}
+			return gotestdox.Unowned
+		},
+		FailSummaryLimit: 1,
+		Formatter:        &gotestdox.TAPFormatter{},
+	}
+	td.Filter()
+	want := `Failures by owner:
+@octocat/api:
+ x example.com/api: Serve
+…and 2 more
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
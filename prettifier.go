@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -30,6 +33,13 @@ import (
 //
 //	Foo has well-formed output
 //
+// Escaped characters are decoded, so that, for example, 'zero-width\u200bspace'
+// becomes 'zero-width' and 'space' joined by a real zero-width space. Control
+// characters remain in their escaped form (for example, \x00 or \a), since
+// printing them would affect the terminal rather than appear in the sentence,
+// but each escape sequence is kept intact, rather than being split into
+// separate words.
+//
 // # Multiword function names
 //
 // Because Go function names are often in camel-case, there's an ambiguity in
@@ -50,64 +60,520 @@ import (
 //
 //	HandleInput closes input after reading
 //
+// # Initialisms
+//
+// A run of capital letters is kept together as a single word, such as "URL"
+// or "JSON". Since there's no way to tell where an initialism ends and a
+// single-letter word begins, a capital letter following an initialism stays
+// part of it, so that:
+//
+//	TestParseJSONB
+//
+// becomes:
+//
+//	Parse JSONB
+//
+// rather than "Parse JSON b". The same goes for a capital letter at the end
+// of the name, or before another word, as in "TestParseURLAThenB". To keep
+// the letter separate, put it in a subtest name: "TestParseURL/A" becomes
+// "Parse URL a".
+//
+// # Version numbers
+//
+// A 'V' (or 'v') followed by digits is treated as a version number, and kept
+// together as a word of its own:
+//
+//	TestUsesV10API
+//
+// becomes:
+//
+//	Uses V10 API
+//
+// This applies at the end of an initialism, too, so 'TestAPIV2' becomes "API
+// V2". The exceptions are a few well-known names that end in a 'V' followed
+// by digits, such as 'RISCV64', which are kept intact.
+//
+// # Type parameters
+//
+// A test name may include type arguments in square brackets, as in the name
+// of an instantiated generic function:
+//
+//	TestMaxReturnsLargest[float64]
+//
+// The type arguments are kept exactly as they are, and by default stay
+// attached to the preceding word:
+//
+//	Max returns largest[float64]
+//
+// To render them as separate words instead, use [WithTypeArgStyle].
+//
+// # Snake case
+//
+// A test name written entirely in snake_case, with no camel-case transitions,
+// such as:
+//
+//	Test_parses_valid_input_v2
+//
+// is simply split into words at each underscore, keeping each word intact,
+// including any digits. The underscore marking the end of a multiword
+// function name has no special meaning here, since there is no way to tell
+// which words make up the function name. So this becomes:
+//
+//	parses valid input v2
+//
+// (The first word is not capitalised, for the reason given in the next
+// section.)
+//
+// # Unexported functions
+//
+// A test for an unexported function may be named with an underscore after
+// 'Test', such as:
+//
+//	Test_helperDoesThing
+//
+// In this case, the lower-case first word is left as it is, rather than being
+// capitalised, so that it matches the function name:
+//
+//	helper does thing
+//
+// This doesn't apply to fuzz tests, such as 'Fuzz_helperParses', whose
+// sentence follows a '[fuzz]' prefix, and so is capitalised as usual.
+//
+// # URL paths
+//
+// A subtest named after an HTTP endpoint, such as 'GET /api/v1/users', has
+// slashes that don't separate subtests. Prettify treats a slash that starts a
+// word of a subtest name (that is, one following a space, or the slash that
+// begins the subtest) as the start of a URL path, and keeps the path intact,
+// up to the next space, so that:
+//
+//	TestRouter/GET_/api/v1/users
+//
+// becomes:
+//
+//	Router GET /api/v1/users
+//
+// Other slashes still separate nested subtests, so 'TestParse/JSON/empty'
+// becomes "Parse JSON empty". Since a path ends only at a space, a path
+// followed by a nested subtest, as in 'TestRouter/GET_/users/returns_200',
+// can't be told apart from a longer path, and becomes "Router GET
+// /users/returns 200".
+//
 // # Debugging
 //
 // If the GOTESTDOX_DEBUG environment variable is set, Prettify will output
 // (copious) debug information to the [DebugWriter] stream, elaborating on its
-// decisions.
+// decisions. To capture this information from a particular [Prettifier]
+// instead, use [WithDebug].
 func Prettify(input string) string {
-	var prefix string
-	p := &prettifier{
-		words: []string{},
-		debug: io.Discard,
+	return NewPrettifier().Prettify(input)
+}
+
+// Split returns the individual words that [Prettify] would make from input,
+// before they are joined into a sentence. This exposes exactly where the
+// lexer found word boundaries, which is useful for debugging or reporting
+// unexpected results from Prettify. For example:
+//
+//	Split("TestParseJSON_ReturnsError") // ["ParseJSON" "returns" "error"]
+//
+// Any prefix that Prettify would add to the sentence, such as the marker for
+// a fuzz test, is not included.
+func Split(input string) []string {
+	return NewPrettifier().Split(input)
+}
+
+// A Prettifier turns test names into sentences, just like [Prettify], but its
+// behaviour can be customised by passing one or more [Option] values to
+// [NewPrettifier]. The zero value is a Prettifier with no options set.
+type Prettifier struct {
+	twoLetterInitialisms bool
+	properNouns          []string
+	debug                io.Writer
+	digitPolicy          DigitPolicy
+	typeArgStyle         TypeArgStyle
+	keepPrefix           bool
+	stripSuitePrefix     bool
+	expansions           map[string]string
+	singleLetterWords    []string
+	prefixes             []string
+	maxWords             int
+}
+
+// An Option configures some aspect of a [Prettifier]'s behaviour.
+type Option func(*Prettifier)
+
+// NewPrettifier returns a [*Prettifier] configured with the given options.
+func NewPrettifier(opts ...Option) *Prettifier {
+	pr := &Prettifier{}
+	for _, opt := range opts {
+		opt(pr)
 	}
-	if os.Getenv("GOTESTDOX_DEBUG") != "" {
-		p.debug = DebugWriter
+	return pr
+}
+
+// WithTwoLetterInitialisms makes the Prettifier always render certain common
+// two-letter words as initialisms, however they are capitalised in the test
+// name. For example, "TestUserIdIsOk" will become "User ID is OK". Plurals are
+// recognised too, so "TestListsIds" will become "Lists IDs". The words
+// recognised are:
+//
+//	DB ID IO IP OK UI VM
+func WithTwoLetterInitialisms() Option {
+	return func(pr *Prettifier) {
+		pr.twoLetterInitialisms = true
 	}
-	p.log("input:", input)
-	if strings.HasPrefix(input, "Fuzz") {
-		input = strings.TrimPrefix(input, "Fuzz")
-		prefix = "[fuzz] "
+}
+
+// WithProperNouns makes the Prettifier preserve the capitalisation of the
+// given nouns, such as product or company names, wherever they appear in the
+// sentence. Nouns are matched ignoring case, so with WithProperNouns("GitHub"),
+// "TestFetchesReposFromGithub" will become "Fetches repos from GitHub".
+func WithProperNouns(nouns ...string) Option {
+	return func(pr *Prettifier) {
+		pr.properNouns = append(pr.properNouns, nouns...)
 	}
-	p.input = []rune(strings.TrimPrefix(input, "Test"))
-	for state := betweenWords; state != nil; {
-		state = state(p)
+}
+
+// WithDebug makes the Prettifier write debug information about its decisions
+// to w, just as [Prettify] does to [DebugWriter] when the GOTESTDOX_DEBUG
+// environment variable is set. The environment variable has no effect on a
+// Prettifier with this option.
+func WithDebug(w io.Writer) Option {
+	return func(pr *Prettifier) {
+		pr.debug = w
+	}
+}
+
+// WithKeepPrefix makes the Prettifier keep the 'Test' or 'Fuzz' prefix of
+// its input, instead of removing it, so that "TestParseJSON" becomes
+// "Test parse JSON". This makes it possible to prettify any camel-case
+// identifier, not just the name of a test: for example, "FuzzyMatchScores"
+// becomes "Fuzzy match scores", rather than being mistaken for a fuzz test.
+func WithKeepPrefix() Option {
+	return func(pr *Prettifier) {
+		pr.keepPrefix = true
 	}
+}
+
+// WithPrefixes makes the Prettifier remove any of the given prefixes from
+// the start of its input, just as it does 'Test', so that it can make
+// sentences from the names of other kinds of function. For example, with
+// WithPrefixes("Benchmark", "Example"), "BenchmarkFooDoesX" becomes "Foo does
+// x", and "ExampleBarWorks" becomes "Bar works". As with Go's own test
+// functions, a prefix counts only if it's followed by something other than a
+// lower-case letter, so "Examples" stays as it is. Only the first matching
+// prefix is removed, and the option has no effect with [WithKeepPrefix].
+func WithPrefixes(prefixes ...string) Option {
+	return func(pr *Prettifier) {
+		pr.prefixes = append(pr.prefixes, prefixes...)
+	}
+}
+
+// WithMaxWords makes the Prettifier shorten any sentence longer than n words
+// to its first n words, followed by an ellipsis ("…") as a word of its own.
+// Words are never split, so an initialism such as "URL", or a word with type
+// arguments, is either kept whole or dropped. A value of zero or less means
+// no limit.
+func WithMaxWords(n int) Option {
+	return func(pr *Prettifier) {
+		pr.maxWords = n
+	}
+}
+
+// WithStripSuitePrefix makes the Prettifier drop the name of a testify
+// suite from the names of its test methods, which 'go test' reports as
+// subtests of the function that runs the suite. For example,
+// "TestUserSuite/TestCreatesAccount" becomes "Creates account", rather than
+// "User suite creates account". A name is treated as a suite method when
+// both the top-level test and the first subtest have test names of their own.
+func WithStripSuitePrefix() Option {
+	return func(pr *Prettifier) {
+		pr.stripSuitePrefix = true
+	}
+}
+
+// WithSingleLetterWords makes the Prettifier keep the given single-letter
+// words in upper case, instead of lower-casing them as it does with other
+// single letters in the middle of a sentence. For example, "TestDoesAThing"
+// becomes "Does a thing", but with WithSingleLetterWords("I", "T"),
+// "TestSliceOfTSortsInPlace" becomes "Slice of T sorts in place". Words are
+// matched exactly, so only upper-case letters should be given.
+func WithSingleLetterWords(words ...string) Option {
+	return func(pr *Prettifier) {
+		pr.singleLetterWords = append(pr.singleLetterWords, words...)
+	}
+}
+
+// WithExpansions makes the Prettifier replace any word in the sentence that
+// is a key of expansions with the corresponding value, so that abbreviations
+// can be spelled out. For example, with an expansion from "config" to
+// "configuration", "TestLoadsConfig" will become "Loads configuration". Only
+// whole words are replaced, ignoring case, and the replacement takes the
+// casing of the value, except that the first word of a sentence stays
+// capitalised.
+func WithExpansions(expansions map[string]string) Option {
+	return func(pr *Prettifier) {
+		if pr.expansions == nil {
+			pr.expansions = map[string]string{}
+		}
+		for word, expansion := range expansions {
+			pr.expansions[strings.ToLower(word)] = expansion
+		}
+	}
+}
+
+// A DigitPolicy determines when a digit following a letter in a test name is
+// kept as part of the same word, rather than starting a new one. There's no
+// single right answer: "bzip2" should stay together, while "Does8Things"
+// should not, and the lexer can't tell the difference. So choose the policy
+// that suits the way your tests are named, using [WithDigitPolicy].
+//
+// Whatever the policy, a run of digits is never split up, digits following a
+// '-' or '=' (as in "-1" or "n=3") stay attached to it, and a version number
+// such as "V2" is kept together.
+type DigitPolicy int
+
+const (
+	// KeepWhenAdjacentToInitialism keeps a digit in the same word only if it
+	// follows an initialism, so "UTF8" and "S390X" stay together, while
+	// "TestBase64" becomes "Base 64", and "bzip2" becomes "bzip 2". This
+	// reads well for most names, since digits after capitals are usually
+	// part of a name, such as an encoding or an architecture, while digits
+	// after a word are usually a quantity. This is the default.
+	KeepWhenAdjacentToInitialism DigitPolicy = iota
+
+	// AlwaysSeparate always starts a new word at a digit following a letter,
+	// so "UTF8" becomes "UTF 8", and "bzip2" becomes "bzip 2".
+	AlwaysSeparate
+
+	// KeepTrailingAfterLetters keeps a digit in the same word as any letter it
+	// follows, so "UTF8" and "bzip2" stay together. The drawback is that
+	// "Does8Things" becomes "does8 things", and "does8things" stays as it is.
+	KeepTrailingAfterLetters
+)
+
+// WithDigitPolicy makes the Prettifier use the given [DigitPolicy] to decide
+// whether digits following letters belong to the same word.
+func WithDigitPolicy(policy DigitPolicy) Option {
+	return func(pr *Prettifier) {
+		pr.digitPolicy = policy
+	}
+}
+
+// A TypeArgStyle determines how the type arguments in a test name such as
+// "TestMax[int,string]" are rendered, using [WithTypeArgStyle]. Whatever the
+// style, the type names themselves are never split or recapitalised.
+type TypeArgStyle int
+
+const (
+	// KeepBrackets keeps the type arguments in their brackets, attached to
+	// the preceding word, so "TestMax[int,string]" becomes "Max[int,string]".
+	// This is the default.
+	KeepBrackets TypeArgStyle = iota
+
+	// SpaceSeparated renders each type argument as a word of its own,
+	// without the brackets, so "TestMax[int,string]" becomes
+	// "Max int string".
+	SpaceSeparated
+)
+
+// WithTypeArgStyle makes the Prettifier render type arguments in test names
+// according to the given [TypeArgStyle].
+func WithTypeArgStyle(style TypeArgStyle) Option {
+	return func(pr *Prettifier) {
+		pr.typeArgStyle = style
+	}
+}
+
+// twoLetterInitialisms lists the words recognised by
+// [WithTwoLetterInitialisms].
+var twoLetterInitialisms = map[string]bool{
+	"DB": true,
+	"ID": true,
+	"IO": true,
+	"IP": true,
+	"OK": true,
+	"UI": true,
+	"VM": true,
+}
+
+// Prettify is like the package-level [Prettify] function, but applies the
+// options configured on pr.
+func (pr *Prettifier) Prettify(input string) string {
+	p := pr.newLexer()
+	prefix := p.split(input)
 	result := prefix + strings.Join(p.words, " ")
 	p.log(fmt.Sprintf("result: %q", result))
 	return result
 }
 
+// Split is like the package-level [Split] function, but applies the options
+// configured on pr.
+func (pr *Prettifier) Split(input string) []string {
+	p := pr.newLexer()
+	p.split(input)
+	return p.words
+}
+
+func (pr *Prettifier) newLexer() *lexer {
+	p := &lexer{
+		Prettifier: pr,
+		words:      []string{},
+		debug:      io.Discard,
+	}
+	switch {
+	case pr.debug != nil:
+		p.debug = pr.debug
+	case os.Getenv("GOTESTDOX_DEBUG") != "":
+		p.debug = DebugWriter
+	}
+	return p
+}
+
 // Heavily inspired by Rob Pike's talk on 'Lexical Scanning in Go':
 // https://www.youtube.com/watch?v=HxaD_trXwRE
-type prettifier struct {
+type lexer struct {
+	*Prettifier
 	debug          io.Writer
 	input          []rune
 	start, pos     int
 	words          []string
 	inSubTest      bool
 	seenUnderscore bool
+	lowerFirst     bool
+}
+
+// split runs the lexer over input, the full name of a test, leaving the
+// resulting words in p.words. It returns any prefix that should be added to
+// the finished sentence.
+func (p *lexer) split(input string) (prefix string) {
+	p.log("input:", input)
+	if p.stripSuitePrefix {
+		if suite, method, ok := strings.Cut(input, "/"); ok && isTestName(suite) && isTestName(method) {
+			p.log("strip suite:", suite)
+			input = method
+		}
+	}
+	if p.keepPrefix {
+		p.input = []rune(input)
+	} else {
+		if strings.HasPrefix(input, "Fuzz") {
+			input = strings.TrimPrefix(input, "Fuzz")
+			prefix = "[fuzz] "
+		}
+		for _, other := range p.prefixes {
+			if rest, ok := cutNamePrefix(input, other); ok {
+				p.log("strip prefix:", other)
+				input = rest
+				break
+			}
+		}
+		p.input = []rune(strings.TrimPrefix(input, "Test"))
+	}
+	p.input = unescape(p.input)
+	if prefix == "" && len(p.input) > 1 && p.input[0] == '_' && unicode.IsLower(p.input[1]) {
+		// name of an unexported function, such as Test_helperDoesThing, but
+		// not a fuzz test, whose sentence follows its '[fuzz]' prefix
+		p.log("lowercase first word")
+		p.lowerFirst = true
+	}
+	var state stateFunc = betweenWords
+	if isSnakeCase(p.input) {
+		p.log("snake case")
+		state = inSnakeCase
+	}
+	for state != nil {
+		state = state(p)
+	}
+	for _, noun := range p.properNouns {
+		p.replaceProperNoun(noun)
+	}
+	p.expand()
+	if p.maxWords > 0 && len(p.words) > p.maxWords {
+		p.log("truncate to", p.maxWords, "words")
+		p.words = append(p.words[:p.maxWords], "…")
+	}
+	return prefix
+}
+
+// expand replaces each word in p.words that has an entry in p.expansions.
+func (p *lexer) expand() {
+	for i, word := range p.words {
+		expansion, ok := p.expansions[strings.ToLower(word)]
+		if !ok || expansion == "" {
+			continue
+		}
+		first, _ := utf8.DecodeRuneInString(word)
+		if i == 0 && unicode.IsUpper(first) {
+			r, size := utf8.DecodeRuneInString(expansion)
+			expansion = string(unicode.ToUpper(r)) + expansion[size:]
+		}
+		p.log(fmt.Sprintf("expand %q to %q", word, expansion))
+		p.words[i] = expansion
+	}
+}
+
+// isTestName reports whether name looks like the name of a Go test function,
+// or a testify suite method: that is, 'Test' followed by something other than
+// a lower-case letter.
+func isTestName(name string) bool {
+	_, ok := cutNamePrefix(name, "Test")
+	return ok
+}
+
+// cutNamePrefix returns name without prefix, and true, if name consists of
+// prefix followed by something other than a lower-case letter, as with the
+// name of a Go test, benchmark, or example function. Otherwise, it returns
+// name unchanged, and false.
+func cutNamePrefix(name, prefix string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok || rest == "" {
+		return name, false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	if unicode.IsLower(r) {
+		return name, false
+	}
+	return rest, true
+}
+
+// replaceProperNoun finds any sequence of one or more consecutive words in
+// p.words which, joined together, match noun (ignoring case and spaces), and
+// replaces it with noun itself.
+func (p *lexer) replaceProperNoun(noun string) {
+	key := strings.ReplaceAll(noun, " ", "")
+	for i := range p.words {
+		joined := ""
+		for j := i; j < len(p.words) && len(joined) < len(key); j++ {
+			joined += p.words[j]
+			if strings.EqualFold(joined, key) {
+				p.log("proper noun", noun)
+				p.words = slices.Replace(p.words, i, j+1, noun)
+				break
+			}
+		}
+	}
 }
 
-func (p *prettifier) backup() {
+func (p *lexer) backup() {
 	p.pos--
 }
 
-func (p *prettifier) skip() {
+func (p *lexer) skip() {
 	p.start = p.pos
 }
 
-func (p *prettifier) prev() rune {
+func (p *lexer) prev() rune {
 	return p.input[p.pos-1]
 }
 
-func (p *prettifier) next() rune {
+func (p *lexer) next() rune {
 	next := p.peek()
 	p.pos++
 	return next
 }
 
-func (p *prettifier) peek() rune {
+func (p *lexer) peek() rune {
 	if p.pos >= len(p.input) {
 		return eof
 	}
@@ -115,7 +581,7 @@ func (p *prettifier) peek() rune {
 	return next
 }
 
-func (p *prettifier) inInitialism() bool {
+func (p *lexer) inInitialism() bool {
 	// deal with Is and As corner cases
 	if len(p.input) > p.start+1 && p.input[p.start+1] == 's' {
 		return false
@@ -128,12 +594,27 @@ func (p *prettifier) inInitialism() bool {
 	return true
 }
 
-func (p *prettifier) emit() {
+// inPunctuation reports whether the word so far consists only of punctuation
+// or symbols, with no letters or digits.
+func (p *lexer) inPunctuation() bool {
+	for _, r := range p.input[p.start:p.pos] {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *lexer) emit() {
 	word := string(p.input[p.start:p.pos])
 	switch {
+	case len(p.words) == 0 && p.lowerFirst:
+		// leave capitalisation as is
 	case len(p.words) == 0:
 		// This is the first word, capitalise it
 		word = cases.Title(language.Und, cases.NoLower).String(word)
+	case len(word) == 1 && slices.Contains(p.singleLetterWords, word):
+		// leave capitalisation as is
 	case len(word) == 1:
 		// Single letter word such as A
 		word = cases.Lower(language.Und).String(word)
@@ -142,26 +623,45 @@ func (p *prettifier) emit() {
 	default:
 		word = cases.Lower(language.Und).String(word)
 	}
+	if p.twoLetterInitialisms {
+		upper := strings.ToUpper(word)
+		switch {
+		case twoLetterInitialisms[upper]:
+			word = upper
+		case len(word) == 3 && strings.HasSuffix(word, "s") && twoLetterInitialisms[upper[:2]]:
+			// plural, such as 'IDs'
+			word = upper[:2] + "s"
+		}
+	}
 	p.log(fmt.Sprintf("emit %q", word))
 	p.words = append(p.words, word)
 	p.skip()
 }
 
-func (p *prettifier) multiWordFunction() {
+func (p *lexer) multiWordFunction() {
 	var fname string
-	for _, w := range p.words {
-		fname += cases.Title(language.Und, cases.NoLower).String(w)
+	for i, w := range p.words {
+		if i == 0 && p.lowerFirst {
+			fname += w
+			continue
+		}
+		// don't capitalise any type arguments
+		w, args, _ := strings.Cut(w, "[")
+		if args != "" {
+			args = "[" + args
+		}
+		fname += cases.Title(language.Und, cases.NoLower).String(w) + args
 	}
 	p.log("multiword function", fname)
 	p.words = []string{fname}
 	p.seenUnderscore = true
 }
 
-func (p *prettifier) log(args ...interface{}) {
+func (p *lexer) log(args ...interface{}) {
 	fmt.Fprintln(p.debug, args...)
 }
 
-func (p *prettifier) logState(stateName string) {
+func (p *lexer) logState(stateName string) {
 	next := "EOF"
 	if p.pos < len(p.input) {
 		next = string(p.input[p.pos])
@@ -173,23 +673,34 @@ func (p *prettifier) logState(stateName string) {
 	))
 }
 
-type stateFunc func(p *prettifier) stateFunc
+type stateFunc func(p *lexer) stateFunc
 
-func betweenWords(p *prettifier) stateFunc {
+func betweenWords(p *lexer) stateFunc {
 	for {
 		p.logState("betweenWords")
 		switch p.next() {
 		case eof:
 			return nil
-		case '_', '/':
+		case '/':
+			if p.pathAt(p.pos - 1) {
+				p.backup()
+				return inPath(betweenWords)
+			}
 			p.skip()
+		case '_':
+			p.skip()
+		case '\\':
+			if escapeLen(p.input[p.pos-1:]) > 0 {
+				p.backup()
+			}
+			return inWord
 		default:
 			return inWord
 		}
 	}
 }
 
-func inWord(p *prettifier) stateFunc {
+func inWord(p *lexer) stateFunc {
 	for {
 		p.logState("inWord")
 		switch r := p.peek(); {
@@ -207,12 +718,24 @@ func inWord(p *prettifier) stateFunc {
 			p.emit()
 			p.inSubTest = true
 			return betweenWords
+		case r == '[' && !p.inSubTest && typeArgsLen(p.input[p.pos:]) > 0:
+			p.emit()
+			return inTypeArgs
+		case r == '\\' && escapeLen(p.input[p.pos:]) > 0:
+			// keep escaped character intact
+			p.pos += escapeLen(p.input[p.pos:])
+			continue
 		case unicode.IsUpper(r):
 			if p.prev() == '-' {
 				// inside hyphenated word
 				p.next()
 				continue
 			}
+			if r == 'V' && p.pos-p.start > 1 && p.inInitialism() && p.versionAt(p.pos) && !vNames[string(p.input[p.start:p.pos+1])] {
+				// initialism followed by a version, such as 'APIV2'
+				p.emit()
+				return betweenWords
+			}
 			if p.inInitialism() {
 				// keep going
 				p.next()
@@ -221,6 +744,14 @@ func inWord(p *prettifier) stateFunc {
 			p.emit()
 			return betweenWords
 		case unicode.IsDigit(r):
+			if p.pos-p.start == 1 && p.versionAt(p.start) {
+				// version number such as 'V2'
+				for unicode.IsDigit(p.peek()) {
+					p.next()
+				}
+				p.emit()
+				return betweenWords
+			}
 			if unicode.IsDigit(p.prev()) {
 				// in a multi-digit number
 				p.next()
@@ -236,7 +767,7 @@ func inWord(p *prettifier) stateFunc {
 				p.next()
 				continue
 			}
-			if p.inInitialism() {
+			if p.keepDigit() {
 				// keep going
 				p.next()
 				continue
@@ -249,6 +780,11 @@ func inWord(p *prettifier) stateFunc {
 				p.next()
 				continue
 			}
+			if p.inPunctuation() {
+				// after leading punctuation, such as an opening quote
+				p.next()
+				continue
+			}
 			if p.input[p.start] == '\'' {
 				// inside a quoted word
 				p.next()
@@ -271,6 +807,237 @@ func inWord(p *prettifier) stateFunc {
 	}
 }
 
+// isSnakeCase reports whether the test name rs is written entirely in
+// snake_case: that is, it has underscores between words, every word starts
+// with a lower-case letter, and it never changes from a lower-case letter or
+// digit to an upper-case letter, as camel-case does. A name such as
+// 'Server_RSTStream_Unblocks', which merely uses underscores to separate
+// camel-case or upper-case words, is not snake_case, and nor are names with
+// type arguments or escaped characters, so that the usual rules apply to
+// them.
+func isSnakeCase(rs []rune) bool {
+	name := strings.TrimLeft(string(rs), "_")
+	if !strings.Contains(name, "_") || strings.ContainsAny(name, `[\`) {
+		return false
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '/'
+	})
+	for _, word := range words {
+		first, _ := utf8.DecodeRuneInString(word)
+		if !unicode.IsLower(first) {
+			return false
+		}
+	}
+	for i := 1; i < len(rs); i++ {
+		if unicode.IsUpper(rs[i]) && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// inSnakeCase splits a snake_case name (see isSnakeCase) into words at each
+// underscore or slash, keeping each word intact, digits and all.
+func inSnakeCase(p *lexer) stateFunc {
+	for {
+		p.logState("inSnakeCase")
+		switch p.peek() {
+		case eof:
+			if p.pos > p.start {
+				p.emit()
+			}
+			return nil
+		case '_', '/':
+			if p.pos > p.start {
+				p.emit()
+			}
+			if p.pathAt(p.pos) {
+				p.skip()
+				return inPath(inSnakeCase)
+			}
+			p.next()
+			p.skip()
+		default:
+			p.next()
+		}
+	}
+}
+
+// pathAt reports whether a URL path, such as '/api/v1/users', starts at
+// position i in the input: that is, whether there is a slash there, in a
+// subtest name, following either a space (which 'go test' shows as an
+// underscore), or the slash that begins the subtest.
+func (p *lexer) pathAt(i int) bool {
+	if i < 1 || i >= len(p.input) || p.input[i] != '/' {
+		return false
+	}
+	switch p.input[i-1] {
+	case '/':
+		return true
+	case '_':
+		// only within a subtest, since a top-level test name has no slash
+		return slices.Contains(p.input[:i-1], '/')
+	}
+	return false
+}
+
+// inPath returns a state that consumes a URL path (see pathAt), up to the
+// next underscore or the end of the input, and adds it to p.words exactly as
+// it is, before continuing in state next.
+func inPath(next stateFunc) stateFunc {
+	return func(p *lexer) stateFunc {
+		p.logState("inPath")
+		for p.peek() != '_' && p.peek() != eof {
+			p.next()
+		}
+		word := string(p.input[p.start:p.pos])
+		p.log(fmt.Sprintf("emit path %q", word))
+		p.words = append(p.words, word)
+		p.inSubTest = true
+		p.skip()
+		return next
+	}
+}
+
+// inTypeArgs consumes a bracketed list of type arguments, such as '[int]',
+// and adds them to p.words according to p.typeArgStyle.
+func inTypeArgs(p *lexer) stateFunc {
+	p.logState("inTypeArgs")
+	n := typeArgsLen(p.input[p.pos:])
+	args := string(p.input[p.pos+1 : p.pos+n-1])
+	p.pos += n
+	p.skip()
+	// the type arguments may end a multiword function name, as in
+	// 'TestMaxOf[int]_ReturnsLargest'
+	endsFunction := p.peek() == '_' && !p.seenUnderscore && !p.inSubTest
+	switch p.typeArgStyle {
+	case SpaceSeparated:
+		p.log("type arguments", args)
+		if endsFunction {
+			p.multiWordFunction()
+		}
+		p.words = append(p.words, splitTypeArgs(args)...)
+	default:
+		p.log("type arguments", "["+args+"]")
+		p.words[len(p.words)-1] += "[" + args + "]"
+		if endsFunction {
+			p.multiWordFunction()
+		}
+	}
+	if p.peek() == '/' {
+		p.inSubTest = true
+	}
+	return betweenWords
+}
+
+// typeArgsLen returns the length of the bracketed list of type arguments at
+// the start of rs, including the brackets, or zero if rs doesn't start with
+// one. Brackets may be nested, as in '[map[string]int]'.
+func typeArgsLen(rs []rune) int {
+	depth := 0
+	for i, r := range rs {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				if i == 1 {
+					// empty brackets
+					return 0
+				}
+				return i + 1
+			}
+		case '/':
+			return 0
+		}
+	}
+	return 0
+}
+
+// splitTypeArgs splits a comma-separated list of type arguments, ignoring any
+// commas inside nested brackets.
+func splitTypeArgs(args string) []string {
+	words := []string{}
+	depth, start := 0, 0
+	for i, r := range args {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				words = append(words, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(words, args[start:])
+}
+
+// vNames lists the names ending in a capital 'V' that are often followed by
+// digits which aren't a version number, such as 'RISCV64'.
+var vNames = map[string]bool{
+	"REV":   true,
+	"RISCV": true,
+}
+
+// versionAt reports whether there is a version number, such as 'V2' or 'v10',
+// at position i in the input.
+func (p *lexer) versionAt(i int) bool {
+	if i+1 >= len(p.input) {
+		return false
+	}
+	return (p.input[i] == 'V' || p.input[i] == 'v') && unicode.IsDigit(p.input[i+1])
+}
+
+// keepDigit reports whether a digit at the current position belongs to the
+// current word, according to p.digitPolicy.
+func (p *lexer) keepDigit() bool {
+	switch p.digitPolicy {
+	case AlwaysSeparate:
+		return !unicode.IsLetter(p.prev())
+	case KeepTrailingAfterLetters:
+		return true
+	}
+	return p.inInitialism()
+}
+
+// escapeLen returns the length of the escape sequence at the start of rs, if
+// it's one that 'go test' would have used to encode an unprintable character
+// in a subtest name, such as \x00 or \u200b. Otherwise, it returns zero.
+func escapeLen(rs []rune) int {
+	if len(rs) < 2 || rs[0] != '\\' {
+		return 0
+	}
+	s := string(rs)
+	r, _, tail, err := strconv.UnquoteChar(s, 0)
+	if err != nil || strconv.IsPrint(r) {
+		return 0
+	}
+	return len(rs) - utf8.RuneCountInString(tail)
+}
+
+// unescape decodes the escape sequences (see escapeLen) in the test name rs,
+// except those for control characters, which are left escaped.
+func unescape(rs []rune) []rune {
+	var out []rune
+	for i := 0; i < len(rs); i++ {
+		if n := escapeLen(rs[i:]); n > 0 {
+			r, _, _, _ := strconv.UnquoteChar(string(rs[i:i+n]), 0)
+			if !unicode.IsControl(r) {
+				out = append(out, r)
+				i += n - 1
+				continue
+			}
+		}
+		out = append(out, rs[i])
+	}
+	return out
+}
+
 const eof rune = 0
 
 // DebugWriter identifies the stream to which debug information should be
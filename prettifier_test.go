@@ -2,6 +2,7 @@ package gotestdox_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/bitfield/gotestdox"
@@ -20,6 +21,369 @@ func TestPrettify(t *testing.T) {
 	}
 }
 
+func TestSplit_ReturnsWordsFoundByLexer(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input string
+		want  []string
+	}{
+		{
+			input: "TestParseJSON_CorrectlyParsesASingleGoTestJSONOutputLine",
+			want:  []string{"ParseJSON", "correctly", "parses", "a", "single", "go", "test", "JSON", "output", "line"},
+		},
+		{
+			input: "TestFoo/has_well-formed_output",
+			want:  []string{"Foo", "has", "well-formed", "output"},
+		},
+		{
+			input: "FuzzPrettify",
+			want:  []string{"Prettify"},
+		},
+		{
+			input: "Test",
+			want:  []string{},
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Split(tc.input)
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestSplit_AgreesWithPrettify(t *testing.T) {
+	t.Parallel()
+	for _, tc := range Cases {
+		if strings.HasPrefix(tc.input, "Fuzz") {
+			continue
+		}
+		got := strings.Join(gotestdox.Split(tc.input), " ")
+		if got != tc.want {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettifierWithTwoLetterInitialisms_CapitalisesKnownInitialisms(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithTwoLetterInitialisms())
+	tcs := []struct {
+		input, want string
+	}{
+		{input: "TestStatusIsOk", want: "Status is OK"},
+		{input: "TestFindsUserById", want: "Finds user by ID"},
+		{input: "TestOpensDbConnection", want: "Opens DB connection"},
+		{input: "TestOk", want: "OK"},
+		{input: "TestFoo/returns_ok", want: "Foo returns OK"},
+		{input: "TestStatusIsOK", want: "Status is OK"},
+		{input: "TestListsIds", want: "Lists IDs"},
+		{input: "TestStartsVms", want: "Starts VMs"},
+	}
+	for _, tc := range tcs {
+		got := pr.Prettify(tc.input)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettifierWithTwoLetterInitialisms_LeavesLongerWordsAlone(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithTwoLetterInitialisms())
+	input := "TestIdentityIsOkay"
+	want := "Identity is okay"
+	got := pr.Prettify(input)
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_DoesNotCapitaliseTwoLetterWordsByDefault(t *testing.T) {
+	t.Parallel()
+	input := "TestStatusIsOk"
+	want := "Status is ok"
+	got := gotestdox.Prettify(input)
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettifierWithProperNouns_PreservesCapitalisationOfNouns(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithProperNouns("GitHub", "Postgres", "macOS"))
+	tcs := []struct {
+		input, want string
+	}{
+		{input: "TestFetchesReposFromGithub", want: "Fetches repos from GitHub"},
+		{input: "TestFetchesReposFromGitHub", want: "Fetches repos from GitHub"},
+		{input: "TestConnectsToPostgresOnStartup", want: "Connects to Postgres on startup"},
+		{input: "TestPostgresIsSupported", want: "Postgres is supported"},
+		{input: "TestBuild/works_on_macos", want: "Build works on macOS"},
+	}
+	for _, tc := range tcs {
+		got := pr.Prettify(tc.input)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettifierWithProperNouns_MatchesOnlyWholeWords(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithProperNouns("Go"))
+	input := "TestGoodInputIsAcceptedByGo"
+	want := "Good input is accepted by Go"
+	got := pr.Prettify(input)
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettifierWithDebug_WritesLexerTraceToGivenWriter(t *testing.T) {
+	t.Parallel()
+	buf := &strings.Builder{}
+	pr := gotestdox.NewPrettifier(gotestdox.WithDebug(buf))
+	pr.Prettify("TestHandleInput_ClosesInput")
+	trace := buf.String()
+	for _, want := range []string{
+		"input: TestHandleInput_ClosesInput\n",
+		"multiword function HandleInput\n",
+		`result: "HandleInput closes input"` + "\n",
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("want trace to contain %q, got:\n%s", want, trace)
+		}
+	}
+}
+
+func TestPrettifierWithDigitPolicy_DecidesWhetherDigitsFollowingLettersStartNewWord(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		policy      gotestdox.DigitPolicy
+		input, want string
+	}{
+		{gotestdox.KeepWhenAdjacentToInitialism, "TestEncodesUTF8", "Encodes UTF8"},
+		{gotestdox.KeepWhenAdjacentToInitialism, "TestOpensBzip2Files", "Opens bzip 2 files"},
+		{gotestdox.KeepWhenAdjacentToInitialism, "TestDoes8Things", "Does 8 things"},
+		{gotestdox.AlwaysSeparate, "TestEncodesUTF8", "Encodes UTF 8"},
+		{gotestdox.AlwaysSeparate, "TestOpensBzip2Files", "Opens bzip 2 files"},
+		{gotestdox.AlwaysSeparate, "TestDoes8Things", "Does 8 things"},
+		{gotestdox.KeepTrailingAfterLetters, "TestEncodesUTF8", "Encodes UTF8"},
+		{gotestdox.KeepTrailingAfterLetters, "TestOpensBzip2Files", "Opens bzip2 files"},
+		{gotestdox.KeepTrailingAfterLetters, "TestDoes8Things", "Does8 things"},
+	}
+	for _, tc := range tcs {
+		pr := gotestdox.NewPrettifier(gotestdox.WithDigitPolicy(tc.policy))
+		got := pr.Prettify(tc.input)
+		if tc.want != got {
+			t.Errorf("policy %d, %q: %s", tc.policy, tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettifierWithDigitPolicy_NeverSplitsNumbersOrNegatives(t *testing.T) {
+	t.Parallel()
+	for _, policy := range []gotestdox.DigitPolicy{
+		gotestdox.KeepWhenAdjacentToInitialism,
+		gotestdox.AlwaysSeparate,
+		gotestdox.KeepTrailingAfterLetters,
+	} {
+		pr := gotestdox.NewPrettifier(gotestdox.WithDigitPolicy(policy))
+		input := "TestColumn/-12_of_n=34"
+		want := "Column -12 of n=34"
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("policy %d: %s", policy, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithKeepPrefix_PrettifiesWholeInput(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithKeepPrefix())
+	tcs := map[string]string{
+		"TestParseJSON":          "Test parse JSON",
+		"FuzzyMatchScores":       "Fuzzy match scores",
+		"HandleInput_ClosesFile": "HandleInput closes file",
+		"TestFoo/has_a_subtest":  "Test foo has a subtest",
+	}
+	for input, want := range tcs {
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithStripSuitePrefix_PrettifiesOnlyTheSuiteMethod(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithStripSuitePrefix())
+	tcs := map[string]string{
+		"TestMySuite/TestSomething":                    "Something",
+		"TestUserStoreSuite/TestCreatesUser":           "Creates user",
+		"TestUserStoreSuite/TestCreatesUser/with_name": "Creates user with name",
+		"TestAPISuite/TestGETReturns200":               "GET returns 200",
+		"TestMySuite":                                  "My suite",
+		"TestFoo/has_a_subtest":                        "Foo has a subtest",
+		"TestFoo/Tested_twice":                         "Foo tested twice",
+		"TestFoo/Test":                                 "Foo test",
+		"FuzzSuite/TestSomething":                      "[fuzz] Suite test something",
+	}
+	for input, want := range tcs {
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithExpansions_ExpandsOnlyStandaloneWords(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithExpansions(map[string]string{
+		"config": "configuration",
+		"DB":     "database",
+		"svc":    "service",
+	}))
+	tcs := map[string]string{
+		"TestLoadsConfig":             "Loads configuration",
+		"TestLoadsCONFIG":             "Loads configuration",
+		"TestConfigIsLoaded":          "Configuration is loaded",
+		"Test_configIsLoaded":         "configuration is loaded",
+		"TestLoadsConfiguration":      "Loads configuration",
+		"TestReconfigures":            "Reconfigures",
+		"TestParsesConfigs":           "Parses configs",
+		"TestConnectsToDB":            "Connects to database",
+		"TestRestarts/svc_on_failure": "Restarts service on failure",
+		"TestLoads/config.yaml_file":  "Loads config.yaml file",
+	}
+	for input, want := range tcs {
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithSingleLetterWords_KeepsOnlyGivenLettersInUpperCase(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithSingleLetterWords("I", "T"))
+	tcs := map[string]string{
+		"TestDoesAThing":           "Does a thing",
+		"TestSliceOfTSortsInPlace": "Slice of T sorts in place",
+		"TestWhatIMeantToSay":      "What I meant to say",
+		"TestAThingIsDone":         "A thing is done",
+		"TestSumsXAndY":            "Sums x and y",
+	}
+	for input, want := range tcs {
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettify_LowercasesSingleLettersByDefault(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]string{
+		"TestDoesAThing":           "Does a thing",
+		"TestSliceOfTSortsInPlace": "Slice of t sorts in place",
+	}
+	for input, want := range tcs {
+		got := gotestdox.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithPrefixes_RemovesPrefixesLikeTest(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithPrefixes("Benchmark", "Example"))
+	tcs := map[string]string{
+		"BenchmarkFooDoesX":          "Foo does x",
+		"BenchmarkParse/small_input": "Parse small input",
+		"ExampleBarWorks":            "Bar works",
+		"ExampleBar_worksWithInput":  "Bar works with input",
+		"Example_helperDoesThing":    "helper does thing",
+		"TestStillWorks":             "Still works",
+		"Examples":                   "Examples",
+		"Example":                    "Example",
+	}
+	for input, want := range tcs {
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettify_KeepsBenchmarkAndExamplePrefixesByDefault(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]string{
+		"BenchmarkFooDoesX": "Benchmark foo does x",
+		"ExampleBarWorks":   "Example bar works",
+	}
+	for input, want := range tcs {
+		got := gotestdox.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithMaxWords_TruncatesLongSentencesAtWordBoundaries(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithMaxWords(4))
+	tcs := map[string]string{
+		"TestShort":                                    "Short",
+		"TestExactlyFourWordsHere":                     "Exactly four words here",
+		"TestParseReturnsErrorWhenInputIsEmpty":        "Parse returns error when …",
+		"TestFetchesAllTheURLsFromTheGivenPage":        "Fetches all the URLs …",
+		"TestMax[float64]ReturnsTheLargestOfTwoValues": "Max[float64] returns the largest …",
+		"TestParseInput_ReturnsErrorOnInvalidInput":    "ParseInput returns error on …",
+	}
+	for input, want := range tcs {
+		got := pr.Prettify(input)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettifierWithMaxWords_KeepsFuzzPrefixOutsideWordCount(t *testing.T) {
+	t.Parallel()
+	pr := gotestdox.NewPrettifier(gotestdox.WithMaxWords(2))
+	want := "[fuzz] Parse never …"
+	got := pr.Prettify("FuzzParseNeverPanicsOnAnyInput")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettifierWithTypeArgStyle_RendersTypeArgumentsAccordingToStyle(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		style       gotestdox.TypeArgStyle
+		input, want string
+	}{
+		{gotestdox.KeepBrackets, "TestMax[int]", "Max[int]"},
+		{gotestdox.KeepBrackets, "TestMax[int,string]", "Max[int,string]"},
+		{gotestdox.KeepBrackets, "TestKeys[map[string]int]", "Keys[map[string]int]"},
+		{gotestdox.SpaceSeparated, "TestMax[int]", "Max int"},
+		{gotestdox.SpaceSeparated, "TestMax[int,string]", "Max int string"},
+		{gotestdox.SpaceSeparated, "TestKeys[map[string]int,T]", "Keys map[string]int T"},
+		{gotestdox.SpaceSeparated, "TestMaxOf[MyType]_ReturnsLargest", "MaxOf MyType returns largest"},
+	}
+	for _, tc := range tcs {
+		pr := gotestdox.NewPrettifier(gotestdox.WithTypeArgStyle(tc.style))
+		got := pr.Prettify(tc.input)
+		if tc.want != got {
+			t.Errorf("style %d, %q: %s", tc.style, tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
 func BenchmarkPrettify(b *testing.B) {
 	input := "TestParseJSON_CorrectlyParsesASingleGoTestJSONOutputLine"
 	for range b.N {
@@ -34,6 +398,13 @@ func ExamplePrettify() {
 	// Foo has well-formed output
 }
 
+func ExampleSplit() {
+	input := "TestHandleInput_ClosesInputAfterReading"
+	fmt.Printf("%q\n", gotestdox.Split(input))
+	// Output:
+	// ["HandleInput" "closes" "input" "after" "reading"]
+}
+
 func ExamplePrettify_underscoreHint() {
 	input := "TestHandleInput_ClosesInputAfterReading"
 	fmt.Println(gotestdox.Prettify(input))
@@ -99,6 +470,11 @@ var Cases = []struct {
 		input: "Test",
 		want:  "",
 	},
+	{
+		name:  "returns an empty sentence for an empty test name",
+		input: "",
+		want:  "",
+	},
 	{
 		name:  "treats underscores as word breaks",
 		input: "Test_Foo_GeneratesValidPDFFile",
@@ -155,10 +531,25 @@ var Cases = []struct {
 		want:  "Calling the function does stuff",
 	},
 	{
-		name:  "eliminates any words containing underscores after splitting",
+		name:  "replaces underscores in subtest names with spaces, without dropping adjacent punctuation",
 		input: "TestSentence/does_x,_correctly",
 		want:  "Sentence does x, correctly",
 	},
+	{
+		name:  "does not drop words containing quotes in table-driven subtest names",
+		input: `TestParse/"a_b",_'c'_and_it's`,
+		want:  `Parse "a b", 'c' and it's`,
+	},
+	{
+		name:  "does not drop words containing a backslash-escaped quote",
+		input: `TestUnquote/\"quoted\"_input`,
+		want:  `Unquote \"quoted\" input`,
+	},
+	{
+		name:  "keeps leading punctuation attached to the word that follows it",
+		input: `TestCall/("quoted")_arg`,
+		want:  `Call ("quoted") arg`,
+	},
 	{
 		name:  "retains hyphenated words in their original form",
 		input: "TestFoo/has_well-formed_output",
@@ -224,6 +615,126 @@ var Cases = []struct {
 		input: "TestFooReturnsIDsAValue",
 		want:  "Foo returns IDs a value",
 	},
+	{
+		name:  "keeps a version number together at the start of the name",
+		input: "TestV2HandlerReturnsJSON",
+		want:  "V2 handler returns JSON",
+	},
+	{
+		name:  "keeps a multi-digit version number together",
+		input: "TestV10HandlerWorks",
+		want:  "V10 handler works",
+	},
+	{
+		name:  "separates a version number from a preceding lower-case word",
+		input: "TestApiV2",
+		want:  "Api V2",
+	},
+	{
+		name:  "separates a version number from a preceding initialism",
+		input: "TestAPIV2",
+		want:  "API V2",
+	},
+	{
+		name:  "separates a multi-digit version number from a preceding initialism",
+		input: "TestHTTPV10Client",
+		want:  "HTTP V10 client",
+	},
+	{
+		name:  "keeps a well-known name ending in 'V' together with its digits",
+		input: "TestRISCV64EndToEnd",
+		want:  "RISCV64 end to end",
+	},
+	{
+		name:  "keeps an instruction name ending in 'V' together with its digits",
+		input: "TestREV16",
+		want:  "REV16",
+	},
+	{
+		name:  "separates a version number from a following initialism",
+		input: "TestUsesV10API",
+		want:  "Uses V10 API",
+	},
+	{
+		name:  "separates a version number from a following word",
+		input: "TestVersionV2IsUsed",
+		want:  "Version V2 is used",
+	},
+	{
+		name:  "keeps a lowercase version number together in a subtest name",
+		input: "TestFoo/v2_handler",
+		want:  "Foo v2 handler",
+	},
+	{
+		name:  "leaves a lowercase first word following 'Test_' uncapitalised",
+		input: "Test_helperDoesThing",
+		want:  "helper does thing",
+	},
+	{
+		name:  "leaves a lowercase single-word name following 'Test_' uncapitalised",
+		input: "Test_helper",
+		want:  "helper",
+	},
+	{
+		name:  "leaves the first word of a lowercase multiword function name uncapitalised",
+		input: "Test_parseJSON_ReturnsError",
+		want:  "parseJSON returns error",
+	},
+	{
+		name:  "capitalises an uppercase first word following 'Test_' as usual",
+		input: "Test_HelperDoesThing",
+		want:  "Helper does thing",
+	},
+	{
+		name:  "capitalises a lowercase first word following 'Fuzz_' as usual",
+		input: "Fuzz_helperParses",
+		want:  "[fuzz] Helper parses",
+	},
+	{
+		name:  "keeps an initialism following 'Fuzz_' in capitals",
+		input: "Fuzz_JSONDecode",
+		want:  "[fuzz] JSON decode",
+	},
+	{
+		name:  "keeps a plural 's' attached to an initialism at the end of the name",
+		input: "TestListIDs",
+		want:  "List IDs",
+	},
+	{
+		name:  "keeps a plural 's' attached to an initialism followed by another word",
+		input: "TestFetchURLsFromPage",
+		want:  "Fetch URLs from page",
+	},
+	{
+		name:  "keeps a plural 's' attached to an initialism that begins the name",
+		input: "TestAPIsAreVersioned",
+		want:  "APIs are versioned",
+	},
+	{
+		name:  "keeps plural initialisms intact in subtest names",
+		input: "TestFoo/lists_APIs_and_URLs",
+		want:  "Foo lists APIs and URLs",
+	},
+	{
+		name:  "keeps a trailing capital letter attached to an initialism",
+		input: "TestParseURLA",
+		want:  "Parse URLA",
+	},
+	{
+		name:  "keeps a capital letter attached to an initialism before another word",
+		input: "TestParseURLAThenB",
+		want:  "Parse URLA then b",
+	},
+	{
+		name:  "keeps a trailing capital letter attached to an initialism that is the whole name",
+		input: "TestURLA",
+		want:  "URLA",
+	},
+	{
+		name:  "separates a single letter from an initialism in a subtest name",
+		input: "TestParseURL/A",
+		want:  "Parse URL a",
+	},
 	{
 		name:  "does not treat 'Is' or 'As' as initialisms",
 		input: "TestThisIsAsItShouldBe",
@@ -234,9 +745,139 @@ var Cases = []struct {
 		input: "TestShiftTransforms255To0",
 		want:  "Shift transforms 255 to 0",
 	},
+	{
+		name:  "keeps escaped control characters intact",
+		input: `TestFoo/null_\x00_byte`,
+		want:  `Foo null \x00 byte`,
+	},
+	{
+		name:  "decodes escaped unprintable Unicode characters",
+		input: `TestFoo/zero-width_\u200b_space`,
+		want:  "Foo zero-width \u200b space",
+	},
+	{
+		name:  "decodes an escaped unprintable character within a word",
+		input: `TestFoo/soft\u00adhyphen`,
+		want:  "Foo soft\u00adhyphen",
+	},
+	{
+		name:  "keeps an escaped escape character intact, so that it isn't sent to the terminal",
+		input: `TestFoo/escape_\x1b_key`,
+		want:  `Foo escape \x1b key`,
+	},
+	{
+		name:  "keeps an escaped unprintable character within a word",
+		input: `TestFoo/bell\aringer`,
+		want:  `Foo bell\aringer`,
+	},
+	{
+		name:  "treats a backslash not forming an escape sequence as an ordinary character",
+		input: `TestPath/dir\name`,
+		want:  `Path dir\name`,
+	},
+	{
+		name:  "preserves commas and colons in table-driven subtest names",
+		input: "TestParse/a,_b:_c",
+		want:  "Parse a, b: c",
+	},
+	{
+		name:  "preserves parentheses and operators in table-driven subtest names",
+		input: "TestEval/f(x)_=_1",
+		want:  "Eval f(x) = 1",
+	},
 	{
 		name:  "correctly formats fuzz test names",
 		input: "FuzzPrettify",
 		want:  "[fuzz] Prettify",
 	},
+	{
+		name:  "keeps a single type argument intact",
+		input: "TestMaxReturnsLargest[float64]",
+		want:  "Max returns largest[float64]",
+	},
+	{
+		name:  "keeps multiple type arguments intact",
+		input: "TestMax[int,string]/works",
+		want:  "Max[int,string] works",
+	},
+	{
+		name:  "keeps type arguments at the end of a multiword function name",
+		input: "TestMaxOf[MyType]_ReturnsLargest",
+		want:  "MaxOf[MyType] returns largest",
+	},
+	{
+		name:  "splits a snake_case name into words at each underscore",
+		input: "Test_parses_valid_input",
+		want:  "parses valid input",
+	},
+	{
+		name:  "keeps words with digits intact in a snake_case name",
+		input: "Test_decodes_base64_and_utf8_input",
+		want:  "decodes base64 and utf8 input",
+	},
+	{
+		name:  "doesn't treat the first underscore in a snake_case name as ending a function name",
+		input: "Test_v2_api_works",
+		want:  "v2 api works",
+	},
+	{
+		name:  "keeps initialisms in a snake_case name",
+		input: "Test_parses_valid_input_with_ID",
+		want:  "parses valid input with ID",
+	},
+	{
+		name:  "splits snake_case subtests too",
+		input: "Test_parses_valid_input/when_empty",
+		want:  "parses valid input when empty",
+	},
+	{
+		name:  "capitalises a snake_case name that doesn't follow 'Test_'",
+		input: "Testparses_valid_input",
+		want:  "Parses valid input",
+	},
+	{
+		name:  "does not treat underscore-separated upper-case words as snake_case",
+		input: "TestServer_RSTStream_Unblocks_Header_Write",
+		want:  "Server RST stream unblocks header write",
+	},
+	{
+		name:  "separates trailing digits in underscore-separated capitalised words",
+		input: "TestServer_Rejects_Headers0",
+		want:  "Server rejects headers 0",
+	},
+	{
+		name:  "does not treat an issue number and capitalised word as snake_case",
+		input: "TestIssue11549_Expect100",
+		want:  "Issue11549 expect 100",
+	},
+	{
+		name:  "keeps a URL path in a subtest name intact",
+		input: "TestRouter/GET_/api/v1/users",
+		want:  "Router GET /api/v1/users",
+	},
+	{
+		name:  "keeps a URL path intact in a camel-case test",
+		input: "TestRouterServesEndpoints/POST_/users/{id}/Avatar",
+		want:  "Router serves endpoints POST /users/{id}/Avatar",
+	},
+	{
+		name:  "ends a URL path at the next space",
+		input: "TestHandler/DELETE_/api/v1/users/42_returns_204",
+		want:  "Handler DELETE /api/v1/users/42 returns 204",
+	},
+	{
+		name:  "keeps a URL path that begins the subtest name",
+		input: "TestRouter//healthz",
+		want:  "Router /healthz",
+	},
+	{
+		name:  "keeps a lone slash as the root path",
+		input: "TestRouter/GET_/",
+		want:  "Router GET /",
+	},
+	{
+		name:  "still separates nested subtests at slashes not following a space",
+		input: "TestParse/JSON/empty",
+		want:  "Parse JSON empty",
+	},
 }
@@ -0,0 +1,64 @@
+package gotestdox
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// raceReport matches a complete report from the race detector, from the
+// line of '=' characters that opens it to the one that closes it.
+var raceReport = regexp.MustCompile(`(?ms)^==================\nWARNING: DATA RACE\n.*?^==================\n`)
+
+// raceFrame matches a stack frame in a race report belonging to a test
+// function (or a closure within one), capturing the name of the test.
+var raceFrame = regexp.MustCompile(`\.((?:Test|Fuzz)\w*)(?:\.func\d+)*\(`)
+
+// attributeRaces moves each race detector report in the output of a package
+// to the output of the failing test whose function appears in its stack
+// traces. With -race, the reports are written to standard error, so when
+// tests run in parallel, they often turn up in the output of the package, or
+// of some other test that happened to be running at the time. output is the
+// package output that isn't associated with any particular test, and tests are
+// the package's results. It returns the updated output and tests.
+//
+// A report that mentions the test it's already attributed to, or mentions no
+// failing test, is left where it is.
+func attributeRaces(output string, tests []Event) (string, []Event) {
+	tests = slices.Clone(tests)
+	move := func(holder string) func(string) string {
+		return func(report string) string {
+			var names []string
+			for _, m := range raceFrame.FindAllStringSubmatch(report, -1) {
+				names = append(names, m[1])
+			}
+			if slices.Contains(names, holder) {
+				return report
+			}
+			for _, name := range names {
+				i := slices.IndexFunc(tests, func(t Event) bool {
+					return t.Failed() && t.Test == name
+				})
+				if i < 0 {
+					continue
+				}
+				tests[i].Output += report
+				if tests[i].Category == "" {
+					tests[i].Category = FailureCategory(tests[i].Output)
+				}
+				return ""
+			}
+			return report
+		}
+	}
+	if strings.Contains(output, "WARNING: DATA RACE") {
+		output = raceReport.ReplaceAllStringFunc(output, move(""))
+	}
+	for i := range tests {
+		if strings.Contains(tests[i].Output, "WARNING: DATA RACE") {
+			holder, _, _ := strings.Cut(tests[i].Test, "/")
+			tests[i].Output = raceReport.ReplaceAllStringFunc(tests[i].Output, move(holder))
+		}
+	}
+	return output, tests
+}
@@ -0,0 +1,93 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+)
+
+// racyTranscript is modelled on the output of 'go test -race -json' for two
+// parallel tests, where the race detector's report was written while the
+// passing test was running, and so was attributed to it by test2json.
+func racyTranscript(t *testing.T) string {
+	t.Helper()
+	report := []string{
+		"==================\n",
+		"WARNING: DATA RACE\n",
+		"Write at 0x00c000014118 by goroutine 8:\n",
+		"  example.com/racy_test.TestRacyCounter.func1()\n",
+		"      /src/racy/racy_test.go:14 +0x44\n",
+		"\n",
+		"Previous write at 0x00c000014118 by goroutine 7:\n",
+		"  example.com/racy_test.TestRacyCounter()\n",
+		"      /src/racy/racy_test.go:16 +0xd8\n",
+		"==================\n",
+	}
+	events := []gotestdox.Event{
+		{Action: "run", Package: "example.com/racy", Test: "TestRacyCounter"},
+		{Action: "output", Package: "example.com/racy", Test: "TestRacyCounter", Output: "=== RUN   TestRacyCounter\n"},
+		{Action: "run", Package: "example.com/racy", Test: "TestFine"},
+		{Action: "output", Package: "example.com/racy", Test: "TestFine", Output: "=== RUN   TestFine\n"},
+	}
+	for _, line := range report {
+		events = append(events, gotestdox.Event{Action: "output", Package: "example.com/racy", Test: "TestFine", Output: line})
+	}
+	events = append(events,
+		gotestdox.Event{Action: "pass", Package: "example.com/racy", Test: "TestFine"},
+		gotestdox.Event{Action: "output", Package: "example.com/racy", Test: "TestRacyCounter", Output: "    testing.go:1617: race detected during execution of test\n"},
+		gotestdox.Event{Action: "fail", Package: "example.com/racy", Test: "TestRacyCounter"},
+		gotestdox.Event{Action: "output", Package: "example.com/racy", Output: "FAIL\n"},
+		gotestdox.Event{Action: "fail", Package: "example.com/racy"},
+	)
+	buf := &strings.Builder{}
+	enc := json.NewEncoder(buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.String()
+}
+
+func TestFilter_AttributesRaceReportToTheFailingTestItMentions(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin:  strings.NewReader(racyTranscript(t)),
+		Stdout: buf,
+		Stderr: io.Discard,
+	}
+	td.Filter()
+	got := buf.String()
+	_, after, ok := strings.Cut(got, " x Racy counter (0.00s) [race]\n")
+	if !ok {
+		t.Fatalf("want failing test with race category, got:\n%s", got)
+	}
+	if !strings.Contains(after, "WARNING: DATA RACE") {
+		t.Errorf("want race report beneath failing test, got:\n%s", got)
+	}
+	if strings.Count(got, "WARNING: DATA RACE") != 1 {
+		t.Errorf("want race report printed once, got:\n%s", got)
+	}
+}
+
+func TestFilter_LeavesRaceReportMentioningNoFailingTestAlone(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	transcript := strings.ReplaceAll(racyTranscript(t), "TestRacyCounter.func1()", "helper()")
+	transcript = strings.ReplaceAll(transcript, "racy_test.TestRacyCounter()", "racy_test.TestFine()")
+	td := gotestdox.TestDoxer{
+		Stdin:  strings.NewReader(transcript),
+		Stdout: buf,
+		Stderr: io.Discard,
+	}
+	td.Filter()
+	if strings.Contains(buf.String(), "WARNING: DATA RACE") {
+		t.Errorf("want race report left with passing test, got:\n%s", buf.String())
+	}
+}
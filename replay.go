@@ -0,0 +1,100 @@
+package gotestdox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Replay is like [TestDoxer.Filter], but reads a saved 'go test -json' stream
+// as though the tests were running now, pausing before each event for as long
+// as elapsed between it and the previous event in the original run. speed
+// scales these pauses: for example, a speed of 2 replays the run twice as fast
+// as it happened. Events without a Time are not delayed, except that a test
+// result with no Time waits for the test's Elapsed time.
+//
+// If ctx is cancelled, Replay stops reading, prints the results of any
+// packages already finished, and sets td.OK to false. This is not an error,
+// but Replay returns any error from [TestDoxer.Filter].
+func (td *TestDoxer) Replay(ctx context.Context, speed float64) (RunResult, error) {
+	td.Stdin = &replayReader{
+		ctx:     ctx,
+		speed:   speed,
+		scanner: bufio.NewScanner(td.Stdin),
+	}
+	result, err := td.Filter()
+	if ctx.Err() != nil {
+		td.OK = false
+		result.OK = false
+	}
+	return result, err
+}
+
+// replayReader reads lines of JSON, delaying each one according to the time it
+// was originally recorded.
+type replayReader struct {
+	ctx     context.Context
+	speed   float64
+	scanner *bufio.Scanner
+	prev    time.Time
+	buf     bytes.Buffer
+}
+
+func (r *replayReader) Read(p []byte) (int, error) {
+	if r.buf.Len() == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		line := r.scanner.Bytes()
+		if !r.wait(r.delay(line)) {
+			return 0, io.EOF
+		}
+		r.buf.Write(line)
+		r.buf.WriteByte('\n')
+	}
+	return r.buf.Read(p)
+}
+
+// delay returns how long to pause before the given line of JSON.
+func (r *replayReader) delay(line []byte) time.Duration {
+	var event struct {
+		Action, Test string
+		Elapsed      float64
+		Time         time.Time
+	}
+	if json.Unmarshal(line, &event) != nil {
+		return 0
+	}
+	var d time.Duration
+	switch {
+	case !event.Time.IsZero():
+		if !r.prev.IsZero() && event.Time.After(r.prev) {
+			d = event.Time.Sub(r.prev)
+		}
+		r.prev = event.Time
+	case event.Test != "" && (event.Action == ActionPass || event.Action == ActionFail):
+		d = time.Duration(event.Elapsed * float64(time.Second))
+	}
+	return time.Duration(float64(d) / r.speed)
+}
+
+// wait pauses for d, reporting false if r.ctx was cancelled in the meantime.
+func (r *replayReader) wait(d time.Duration) bool {
+	if d <= 0 {
+		return r.ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
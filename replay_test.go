@@ -0,0 +1,62 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReplay_PausesBetweenEventsScaledBySpeed(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Time":"2024-01-01T00:00:00Z","Action":"pass","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"p","Test":"TestB"}
+{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"p"}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+	}
+	start := time.Now()
+	td.Replay(context.Background(), 10)
+	elapsed := time.Since(start)
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("want replay to take at least 200ms, took %s", elapsed)
+	}
+	if !td.OK {
+		t.Error("want OK")
+	}
+	want := "p:\n ✔ A (0.00s)\n ✔ B (0.00s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestReplay_StopsAndIsNotOKWhenCancelled(t *testing.T) {
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdin: strings.NewReader(`{"Time":"2024-01-01T00:00:00Z","Action":"pass","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T00:00:00Z","Action":"pass","Package":"p"}
+{"Time":"2024-01-01T01:00:00Z","Action":"pass","Package":"q","Test":"TestB"}
+{"Time":"2024-01-01T01:00:00Z","Action":"pass","Package":"q"}`),
+		Stdout: buf,
+		Stderr: io.Discard,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	td.Replay(ctx, 1)
+	if td.OK {
+		t.Error("want not OK")
+	}
+	want := "p:\n ✔ A (0.00s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
@@ -0,0 +1,180 @@
+package gotestdox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// execWithRetries runs the tests just as [TestDoxer.ExecGoTest] does, but
+// collects the output instead of filtering it straight away. Any tests that
+// failed are run again, up to td.Retry times, until they pass, and then the
+// output of the first run is filtered, with the result of each test that
+// passed on a retry substituted for its original failure.
+//
+// If 'go test' exited with a non-zero status for some reason other than the
+// failures being retried, such as a build error, td.OK is false, and the
+// reason is reported to td.Stderr, but if it couldn't be run at all, that's
+// an error.
+func (td *TestDoxer) execWithRetries(userArgs []string) (RunResult, error) {
+	if td.Notify {
+		defer td.notify()
+	}
+	lines, err := td.collect(userArgs)
+	failed := failedTests(lines)
+	if len(failed) > 0 && isExitError(err) {
+		// just the test failures we're about to retry
+		err = nil
+	}
+	recovered := map[testKey]Event{}
+	for retry := 1; retry <= td.Retry && len(failed) > 0 && err == nil; retry++ {
+		var retryLines []string
+		retryLines, err = td.collect(withRunPattern(userArgs, runPattern(failed)))
+		if isExitError(err) {
+			err = nil
+		}
+		for _, line := range retryLines {
+			event, perr := ParseJSON(line)
+			if perr != nil || !event.IsTestResult() || !event.Passed() {
+				continue
+			}
+			key := testKey{event.Package, event.Test}
+			if !failed[key] {
+				continue
+			}
+			event.Retry = retry
+			recovered[key] = event
+			delete(failed, key)
+		}
+	}
+	td.Stdin = td.saving(strings.NewReader(strings.Join(merge(lines, recovered, failed), "\n") + "\n"))
+	result, ferr := td.Filter()
+	if ferr != nil {
+		return result, ferr
+	}
+	if err != nil {
+		td.OK = false
+		result.OK = false
+		if !isExitError(err) {
+			return result, err
+		}
+		fmt.Fprintln(td.Stderr, err)
+	}
+	return result, nil
+}
+
+// collect runs 'go test -json' with args, and returns its output as a slice of
+// lines.
+func (td *TestDoxer) collect(args []string) ([]string, error) {
+	cmd := exec.Command(td.goCommand(), append([]string{"test", "-json"}, args...)...)
+	cmd.Stderr = td.goStderr()
+	td.echo(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("%v %w", cmd.Args, err)
+	}
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, err
+}
+
+// isExitError reports whether err is the result of a command exiting with
+// non-zero status, as 'go test' does when a test fails.
+func isExitError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// failedTests returns the set of tests (including subtests) reported as
+// failed in lines of 'go test -json' output.
+func failedTests(lines []string) map[testKey]bool {
+	failed := map[testKey]bool{}
+	for _, line := range lines {
+		event, err := ParseJSON(line)
+		if err == nil && event.IsTestResult() && event.Failed() {
+			failed[testKey{event.Package, event.Test}] = true
+		}
+	}
+	return failed
+}
+
+// runPattern returns a regular expression, suitable for the '-run' flag of
+// 'go test', matching exactly the top-level tests among failed. Subtests are
+// rerun along with their parents.
+func runPattern(failed map[testKey]bool) string {
+	names := []string{}
+	for key := range failed {
+		name, _, _ := strings.Cut(key.test, "/")
+		name = regexp.QuoteMeta(name)
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return "^(" + strings.Join(names, "|") + ")$"
+}
+
+// withRunPattern returns a copy of userArgs with a '-run' flag added, so that
+// only the tests matching pattern are run. The flag is added before any
+// '-args', since everything after that is passed to the test binary.
+func withRunPattern(userArgs []string, pattern string) []string {
+	i := slices.IndexFunc(userArgs, func(arg string) bool {
+		return arg == "-args" || arg == "--args"
+	})
+	if i < 0 {
+		i = len(userArgs)
+	}
+	return slices.Insert(slices.Clone(userArgs), i, "-run", pattern)
+}
+
+// merge returns lines, the output of the first run, with the result of each
+// recovered test substituted for its original failure. A failed package all of
+// whose failed tests were recovered is marked as passed. still lists the tests
+// that never passed.
+func merge(lines []string, recovered map[testKey]Event, still map[testKey]bool) []string {
+	failing := map[string]bool{}
+	for key := range still {
+		failing[key.pkg] = true
+	}
+	flaky := map[string]bool{}
+	for key := range recovered {
+		flaky[key.pkg] = true
+	}
+	merged := make([]string, 0, len(lines))
+	for _, line := range lines {
+		event, err := ParseJSON(line)
+		switch {
+		case err != nil:
+		case event.IsTestResult():
+			if r, ok := recovered[testKey{event.Package, event.Test}]; ok {
+				line = reencode(line, r)
+			}
+		case event.IsPackageResult() && event.Failed():
+			if flaky[event.Package] && !failing[event.Package] {
+				event.Action = ActionPass
+				line = reencode(line, event)
+			}
+		}
+		merged = append(merged, line)
+	}
+	return merged
+}
+
+// reencode returns the JSON encoding of event, to replace line. If event
+// can't be encoded, line is returned unchanged.
+func reencode(line string, event Event) string {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return line
+	}
+	return string(data)
+}
@@ -0,0 +1,116 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecGoTestWithRetry_MarksTestsPassingOnRetryAsFlaky(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	color.NoColor = true
+	dir := t.TempDir()
+	fakeGo := filepath.Join(dir, "go")
+	err := os.WriteFile(fakeGo, []byte(`#!/bin/sh
+if [ -f "$0.ran" ]; then
+	echo '{"Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":0.01}'
+	echo '{"Action":"pass","Package":"p"}'
+	exit 0
+fi
+touch "$0.ran"
+echo '{"Action":"pass","Package":"p","Test":"TestSteady"}'
+echo '{"Action":"fail","Package":"p","Test":"TestFlaky"}'
+echo '{"Action":"fail","Package":"p"}'
+exit 1
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdout:    buf,
+		Stderr:    io.Discard,
+		GoCommand: fakeGo,
+		Retry:     1,
+	}
+	result, err := td.ExecGoTest(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "p:\n ✔ Flaky (0.01s) (flaky, passed on retry 1)\n ✔ Steady (0.00s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if !result.OK {
+		t.Error("want ok")
+	}
+	if result.Passed != 2 || result.Failed != 0 {
+		t.Errorf("want 2 passed and 0 failed, got %d and %d", result.Passed, result.Failed)
+	}
+}
+
+func TestExecGoTestWithRetry_SavesMergedOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir := t.TempDir()
+	fakeGo := filepath.Join(dir, "go")
+	err := os.WriteFile(fakeGo, []byte(`#!/bin/sh
+if [ -f "$0.ran" ]; then
+	echo '{"Action":"pass","Package":"p","Test":"TestFlaky"}'
+	exit 0
+fi
+touch "$0.ran"
+echo '{"Action":"fail","Package":"p","Test":"TestFlaky"}'
+echo '{"Action":"fail","Package":"p"}'
+exit 1
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := &bytes.Buffer{}
+	td := gotestdox.TestDoxer{
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+		GoCommand: fakeGo,
+		Retry:     1,
+		Save:      saved,
+	}
+	td.ExecGoTest(nil)
+	replay := gotestdox.TestDoxer{
+		Stdin:  saved,
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := replay.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK || result.Passed != 1 {
+		t.Errorf("want saved output to show 1 test passing on retry, got:\n%s", saved)
+	}
+}
+
+func TestEventString_MarksTestPassingOnRetryAsFlaky(t *testing.T) {
+	color.NoColor = true
+	event := gotestdox.Event{
+		Action:   gotestdox.ActionPass,
+		Sentence: "Flaky thing works",
+		Elapsed:  0.5,
+		Retry:    2,
+	}
+	want := " ✔ Flaky thing works (0.50s) (flaky, passed on retry 2)"
+	got := event.String()
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
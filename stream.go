@@ -0,0 +1,45 @@
+//go:build go1.23
+
+package gotestdox
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Stream returns an iterator over the events read from r, which should
+// contain the output of 'go test -json', one event per line. Events are
+// yielded as soon as each line is read, so results can be processed while
+// the tests are still running:
+//
+//	for event, err := range gotestdox.Stream(r) {
+//		if err != nil {
+//			log.Println(err)
+//			continue
+//		}
+//		...
+//	}
+//
+// As with [TestDoxer.Filter], lines that aren't JSON objects at all are
+// skipped. A line that can't be parsed yields an error from [ParseJSON],
+// after which iteration continues with the next line. An error reading from
+// r is yielded last.
+func Stream(r io.Reader) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+				continue
+			}
+			if !yield(ParseJSON(line)) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(Event{}, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+//go:build go1.23
+
+package gotestdox_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStream_YieldsEachEventAndParseErrorInOrder(t *testing.T) {
+	t.Parallel()
+	input := `preamble
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Pack
+{"Action":"pass","Package":"p"}`
+	var got []string
+	for event, err := range gotestdox.Stream(strings.NewReader(input)) {
+		if err != nil {
+			got = append(got, "error")
+			continue
+		}
+		got = append(got, event.Action+" "+event.Test)
+	}
+	want := []string{"pass TestA", "error", "pass "}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStream_StopsWhenLoopBreaks(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestB"}`
+	count := 0
+	for range gotestdox.Stream(strings.NewReader(input)) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("want 1 event, got %d", count)
+	}
+}
+
+func TestStream_YieldsReadErrorLast(t *testing.T) {
+	t.Parallel()
+	errBoom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+`), iotest.ErrReader(errBoom))
+	var errs []error
+	for _, err := range gotestdox.Stream(r) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], errBoom) {
+		t.Errorf("want an event, then error %v, got %v", errBoom, errs)
+	}
+}
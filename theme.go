@@ -0,0 +1,74 @@
+package gotestdox
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// A Theme maps each role in gotestdox's output, such as [RolePass] or
+// [RoleFail], to the colour used to display it. Roles missing from the theme
+// are displayed without colour.
+type Theme map[string]color.Attribute
+
+// Roles that can be assigned colours by a [Theme].
+const (
+	RolePass = "pass"
+	RoleFail = "fail"
+	RoleSkip = "skip"
+	RoleSlow = "slow"
+)
+
+// Themes contains the named themes available to the '-theme' flag. The
+// "mono" theme uses no colour at all, distinguishing results by their symbols
+// alone.
+var Themes = map[string]Theme{
+	"default": DefaultTheme,
+	"dark": {
+		RolePass: color.FgHiGreen,
+		RoleFail: color.FgHiRed,
+		RoleSkip: color.FgHiYellow,
+		RoleSlow: color.FgHiMagenta,
+	},
+	"light": {
+		RolePass: color.FgGreen,
+		RoleFail: color.FgRed,
+		RoleSkip: color.FgBlue,
+		RoleSlow: color.FgMagenta,
+	},
+	"mono": {},
+}
+
+// DefaultTheme is the [Theme] used unless some other theme is selected.
+var DefaultTheme = Theme{
+	RolePass: color.FgGreen,
+	RoleFail: color.FgRed,
+	RoleSkip: color.FgYellow,
+	RoleSlow: color.FgYellow,
+}
+
+// LookupTheme returns the named theme from [Themes], or an error if there is
+// no such theme.
+func LookupTheme(name string) (Theme, error) {
+	theme, ok := Themes[name]
+	if !ok {
+		names := []string{}
+		for name := range Themes {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown theme %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	return theme, nil
+}
+
+// paint returns s in the colour that t assigns to role, if any.
+func (t Theme) paint(role, s string) string {
+	attr, ok := t[role]
+	if !ok {
+		return s
+	}
+	return color.New(attr).Sprint(s)
+}
@@ -0,0 +1,69 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLookupTheme_ReturnsEachNamedTheme(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"default", "dark", "light", "mono"} {
+		_, err := gotestdox.LookupTheme(name)
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+}
+
+func TestLookupTheme_ErrorsOnUnknownTheme(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.LookupTheme("bogus")
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestThemes_AllAssignColoursToTheSameRoles(t *testing.T) {
+	t.Parallel()
+	roles := []string{gotestdox.RolePass, gotestdox.RoleFail, gotestdox.RoleSkip, gotestdox.RoleSlow}
+	for name, theme := range gotestdox.Themes {
+		if name == "mono" {
+			continue
+		}
+		for _, role := range roles {
+			if _, ok := theme[role]; !ok {
+				t.Errorf("theme %q has no colour for role %q", name, role)
+			}
+		}
+	}
+}
+
+func TestTextFormatter_UsesThemeColours(t *testing.T) {
+	color.NoColor = false
+	defer func() { color.NoColor = true }()
+	f := &gotestdox.TextFormatter{Theme: gotestdox.Themes["dark"], NoPackageHeaders: true}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "pass", Sentence: "A"}},
+	}, "p")
+	want := color.New(color.FgHiGreen).Sprint("✔")
+	if !strings.Contains(got, want) {
+		t.Errorf("want %q in %q", want, got)
+	}
+}
+
+func TestTextFormatter_UsesNoColourWithMonoTheme(t *testing.T) {
+	color.NoColor = false
+	defer func() { color.NoColor = true }()
+	f := &gotestdox.TextFormatter{Theme: gotestdox.Themes["mono"], NoPackageHeaders: true}
+	want := " ✔ A (0.00s)\n x B (0.00s)\n\n"
+	got := format(t, f, map[string][]gotestdox.Event{
+		"p": {{Action: "pass", Sentence: "A"}, {Action: "fail", Sentence: "B"}},
+	}, "p")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,269 @@
+package gotestdox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// A Browser is a [Formatter] which, instead of printing the results, collects
+// them so that they can be explored interactively with [Browser.Browse], as a
+// tree of packages and tests. Failing packages start out expanded, and
+// selecting a test shows its output.
+type Browser struct {
+	// Theme, if set, determines the colours used, instead of [DefaultTheme].
+	Theme Theme
+
+	// Height is the number of lines available on the screen. If it is zero,
+	// 24 lines are assumed.
+	Height int
+
+	packages       []*browserPackage
+	cursor, offset int
+}
+
+// browserPackage holds the results for one package in a [Browser], along
+// with which parts of the tree are currently expanded.
+type browserPackage struct {
+	event Event
+	tests []Event
+	open  bool
+	shown map[int]bool
+}
+
+// browserRow identifies one line of the tree: a package, if test is -1, or a
+// test, if line is -1, or otherwise a line of that test's output.
+type browserRow struct {
+	pkg, test, line int
+}
+
+// Header starts a new package in the tree.
+func (b *Browser) Header(pkg Event) ([]byte, error) {
+	b.packages = append(b.packages, &browserPackage{
+		event: pkg,
+		open:  pkg.Failed(),
+		shown: map[int]bool{},
+	})
+	return nil, nil
+}
+
+// Format adds test to the current package.
+func (b *Browser) Format(test Event) ([]byte, error) {
+	if len(b.packages) == 0 {
+		return nil, errors.New("test result outside any package")
+	}
+	pkg := b.packages[len(b.packages)-1]
+	pkg.tests = append(pkg.tests, test)
+	return nil, nil
+}
+
+// Footer does nothing, since the package is complete once its tests have been
+// added.
+func (b *Browser) Footer(pkg Event) ([]byte, error) {
+	return nil, nil
+}
+
+// Browse draws the tree on out, and responds to keys read from in, until the
+// user presses 'q' or in is exhausted. The up and down arrow keys (or 'k' and
+// 'j') move the selection, and Enter (or space) expands or collapses the
+// selected package, or shows or hides the output of the selected test.
+//
+// Browse expects in to be a terminal in raw mode, so that keys can be read
+// one at a time.
+func (b *Browser) Browse(in io.Reader, out io.Writer) error {
+	keys := bufio.NewReader(in)
+	for {
+		if err := b.draw(out); err != nil {
+			return err
+		}
+		key, err := readKey(keys)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "up", "k":
+			b.move(-1)
+		case "down", "j":
+			b.move(1)
+		case "\r", "\n", " ":
+			b.toggle()
+		case "q", "\x03":
+			return nil
+		}
+	}
+}
+
+// readKey returns the next key pressed, translating the escape sequences for
+// the arrow keys into "up" and "down".
+func readKey(keys *bufio.Reader) (string, error) {
+	r, _, err := keys.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	if r != '\x1b' {
+		return string(r), nil
+	}
+	seq := make([]byte, 2)
+	if _, err := io.ReadFull(keys, seq); err != nil {
+		return "", err
+	}
+	switch string(seq) {
+	case "[A":
+		return "up", nil
+	case "[B":
+		return "down", nil
+	}
+	return "", nil
+}
+
+// rows returns the lines of the tree as currently expanded.
+func (b *Browser) rows() []browserRow {
+	rows := []browserRow{}
+	for i, pkg := range b.packages {
+		rows = append(rows, browserRow{i, -1, -1})
+		if !pkg.open {
+			continue
+		}
+		for j, test := range pkg.tests {
+			rows = append(rows, browserRow{i, j, -1})
+			if !pkg.shown[j] {
+				continue
+			}
+			for k := range outputLines(test) {
+				rows = append(rows, browserRow{i, j, k})
+			}
+		}
+	}
+	return rows
+}
+
+// move moves the selection by delta packages or tests, skipping over lines of
+// test output.
+func (b *Browser) move(delta int) {
+	rows := b.rows()
+	for i := b.cursor + delta; i >= 0 && i < len(rows); i += delta {
+		if rows[i].line == -1 {
+			b.cursor = i
+			return
+		}
+	}
+}
+
+// toggle expands or collapses the selected package, or shows or hides the
+// output of the selected test.
+func (b *Browser) toggle() {
+	rows := b.rows()
+	if b.cursor >= len(rows) {
+		return
+	}
+	row := rows[b.cursor]
+	pkg := b.packages[row.pkg]
+	if row.test == -1 {
+		pkg.open = !pkg.open
+		return
+	}
+	pkg.shown[row.test] = !pkg.shown[row.test]
+}
+
+// draw clears the screen and prints as much of the tree as will fit, scrolled
+// so as to include the selection, followed by a line of help.
+func (b *Browser) draw(out io.Writer) error {
+	height := b.Height
+	if height == 0 {
+		height = 24
+	}
+	lines := max(height-1, 1)
+	rows := b.rows()
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+lines {
+		b.offset = b.cursor - lines + 1
+	}
+	buf := &strings.Builder{}
+	buf.WriteString("\x1b[H\x1b[2J")
+	for i := b.offset; i < len(rows) && i < b.offset+lines; i++ {
+		marker := "  "
+		if i == b.cursor {
+			marker = "> "
+		}
+		buf.WriteString(marker + b.render(rows[i]) + "\r\n")
+	}
+	buf.WriteString("↑/↓ move, enter expand/collapse, q quit")
+	_, err := io.WriteString(out, buf.String())
+	return err
+}
+
+// render formats one line of the tree.
+func (b *Browser) render(row browserRow) string {
+	theme := b.Theme
+	if theme == nil {
+		theme = DefaultTheme
+	}
+	pkg := b.packages[row.pkg]
+	switch {
+	case row.test == -1:
+		arrow := "▸"
+		if pkg.open {
+			arrow = "▾"
+		}
+		status := theme.paint(RolePass, "✔")
+		if pkg.event.Failed() {
+			status = theme.paint(RoleFail, "x")
+		}
+		return fmt.Sprintf("%s %s %s", arrow, status, pkg.event.Package)
+	case row.line == -1:
+		return "  " + pkg.tests[row.test].render(theme, elapsedStyle{precision: 2})
+	}
+	return "      " + outputLines(pkg.tests[row.test])[row.line]
+}
+
+// outputLines returns the output of test as a slice of lines, without the
+// indentation added by 'go test'.
+func outputLines(test Event) []string {
+	output := strings.TrimRight(test.Output, "\n")
+	if output == "" {
+		return nil
+	}
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "    ")
+	}
+	return lines
+}
+
+// browse lets the user explore the results collected by b, using the
+// terminal attached to standard output. Keys are read from standard input,
+// if that is a terminal, or otherwise from the controlling terminal, so that
+// results piped to gotestdox can still be browsed.
+func browse(b *Browser) error {
+	keys := os.Stdin
+	if !Interactive(keys) {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return fmt.Errorf("no terminal to read keys from: %w", err)
+		}
+		defer tty.Close()
+		keys = tty
+	}
+	state, err := term.MakeRaw(int(keys.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(keys.Fd()), state)
+	if _, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		b.Height = height
+	}
+	// use the alternate screen, leaving the original screen intact on exit
+	fmt.Print("\x1b[?1049h")
+	defer fmt.Print("\x1b[?1049l")
+	return b.Browse(keys, os.Stdout)
+}
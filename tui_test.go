@@ -0,0 +1,69 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBrowser_ExpandsFailingPackagesAndShowsOutputOfSelectedTest(t *testing.T) {
+	color.NoColor = true
+	b := &gotestdox.Browser{}
+	addPackage(t, b, gotestdox.Event{Action: "pass", Package: "p"},
+		gotestdox.Event{Action: "pass", Sentence: "A"},
+	)
+	addPackage(t, b, gotestdox.Event{Action: "fail", Package: "q"},
+		gotestdox.Event{Action: "fail", Sentence: "B", Output: "    q_test.go:9: oh no\n"},
+	)
+	buf := &bytes.Buffer{}
+	// down twice, with 'j' and the arrow key, then Enter, then quit
+	err := b.Browse(strings.NewReader("j\x1b[B\rq"), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "  ▸ ✔ p\r\n  ▾ x q\r\n>    x B (0.00s)\r\n        q_test.go:9: oh no\r\n↑/↓ move, enter expand/collapse, q quit"
+	if got := lastScreen(buf.String()); want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestBrowser_ScrollsToKeepSelectionOnScreen(t *testing.T) {
+	color.NoColor = true
+	b := &gotestdox.Browser{Height: 3}
+	for _, pkg := range []string{"p", "q", "r", "s"} {
+		addPackage(t, b, gotestdox.Event{Action: "pass", Package: pkg})
+	}
+	buf := &bytes.Buffer{}
+	err := b.Browse(strings.NewReader("jjj"), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "  ▸ ✔ r\r\n> ▸ ✔ s\r\n↑/↓ move, enter expand/collapse, q quit"
+	if got := lastScreen(buf.String()); want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func addPackage(t *testing.T, f gotestdox.Formatter, pkg gotestdox.Event, tests ...gotestdox.Event) {
+	t.Helper()
+	if _, err := f.Header(pkg); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		if _, err := f.Format(test); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.Footer(pkg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// lastScreen returns what was drawn after the screen was last cleared.
+func lastScreen(s string) string {
+	return s[strings.LastIndex(s, "\x1b[2J")+len("\x1b[2J"):]
+}
@@ -0,0 +1,54 @@
+package gotestdox
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// ModuleOf returns the path of the module, among modules, that contains the
+// package pkg, or the empty string if none does. If modules are nested, the
+// innermost one that contains pkg is chosen.
+func ModuleOf(modules []string, pkg string) string {
+	module := ""
+	for _, m := range modules {
+		if (pkg == m || strings.HasPrefix(pkg, m+"/")) && len(m) > len(module) {
+			module = m
+		}
+	}
+	return module
+}
+
+// workspaceModules returns the paths of the modules in the Go workspace
+// containing the current directory, as reported by 'go list -m', or nil if
+// it isn't in a workspace (according to 'go env GOWORK'), or the modules
+// can't be listed.
+func (td *TestDoxer) workspaceModules() []string {
+	out, err := exec.Command(td.goCommand(), "env", "GOWORK").Output()
+	if err != nil {
+		return nil
+	}
+	if gowork := strings.TrimSpace(string(out)); gowork == "" || gowork == "off" {
+		return nil
+	}
+	out, err = exec.Command(td.goCommand(), "list", "-m", "-f", "{{.Path}}").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// moduleNames returns the function to be used as [TextFormatter.ModuleOf], if
+// td.ShowModules is set and the current directory is in a Go workspace with
+// more than one module, or nil otherwise.
+func (td *TestDoxer) moduleNames() func(pkg string) string {
+	if !td.ShowModules {
+		return nil
+	}
+	modules := td.workspaceModules()
+	if len(modules) < 2 {
+		return nil
+	}
+	return func(pkg string) string {
+		return ModuleOf(modules, pkg)
+	}
+}
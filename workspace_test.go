@@ -0,0 +1,56 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleOf_FindsInnermostModuleContainingPackage(t *testing.T) {
+	t.Parallel()
+	modules := []string{"example.com/app", "example.com/lib", "example.com/app/tools"}
+	tcs := map[string]string{
+		"example.com/app":             "example.com/app",
+		"example.com/app/internal/db": "example.com/app",
+		"example.com/app/tools/gen":   "example.com/app/tools",
+		"example.com/lib/util":        "example.com/lib",
+		"example.com/library":         "",
+		"other.org/x":                 "",
+	}
+	for pkg, want := range tcs {
+		got := gotestdox.ModuleOf(modules, pkg)
+		if want != got {
+			t.Errorf("%q: want %q, got %q", pkg, want, got)
+		}
+	}
+}
+
+func TestTextFormatterWithModuleOf_PrefixesHeadersWithModule(t *testing.T) {
+	color.NoColor = true
+	modules := []string{"example.com/app", "example.com/lib"}
+	f := &gotestdox.TextFormatter{
+		ModuleOf: func(pkg string) string {
+			return gotestdox.ModuleOf(modules, pkg)
+		},
+	}
+	got := format(t, f, map[string][]gotestdox.Event{
+		"example.com/app/util": {{Action: "pass", Sentence: "Formats dates"}},
+		"example.com/lib/util": {{Action: "pass", Sentence: "Parses dates"}},
+		"other.org/x":          {{Action: "pass", Sentence: "Works"}},
+	}, "example.com/app/util", "example.com/lib/util", "other.org/x")
+	want := `[example.com/app] example.com/app/util:
+ ✔ Formats dates (0.00s)
+
+[example.com/lib] example.com/lib/util:
+ ✔ Parses dates (0.00s)
+
+other.org/x:
+ ✔ Works (0.00s)
+
+`
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}